@@ -0,0 +1,105 @@
+package crawler
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"sync"
+)
+
+// cacheTransport serves repeated GET requests for a URL from memory for the life
+// of the client, so a sitemap or page reached twice in one run is only fetched
+// once. Only complete 200 responses are kept; errors, redirects, and other
+// statuses are fetched again each time. Concurrent requests for a URL that is
+// already being fetched wait for that fetch instead of starting another.
+type cacheTransport struct {
+	base http.RoundTripper
+
+	mu      sync.Mutex
+	entries map[string]*cacheEntry
+}
+
+// cacheEntry is a cached response, or one still being fetched until ready is closed.
+type cacheEntry struct {
+	ready  chan struct{}
+	ok     bool // Whether the fetch gave a response that can be served from the cache
+	status string
+	proto  string
+	header http.Header
+	body   []byte
+}
+
+// RoundTrip serves the request from the cache when it can, and otherwise sends it
+// with the base transport, caching the response if it is a 200.
+func (t *cacheTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Method != http.MethodGet || req.Header.Get("Range") != "" {
+		return t.base.RoundTrip(req)
+	}
+	key := req.URL.String()
+
+	for {
+		t.mu.Lock()
+		entry, found := t.entries[key]
+		if !found {
+			entry = &cacheEntry{ready: make(chan struct{})}
+			t.entries[key] = entry
+		}
+		t.mu.Unlock()
+
+		if !found {
+			return t.fetch(req, key, entry)
+		}
+
+		select {
+		case <-entry.ready:
+		case <-req.Context().Done():
+			return nil, req.Context().Err()
+		}
+		if entry.ok {
+			return entry.response(req), nil
+		}
+		// The other fetch couldn't be cached, so it was dropped; try again ourselves
+	}
+}
+
+// fetch sends the request for a new entry and fills it in, or drops the entry
+// when the response can't be cached.
+func (t *cacheTransport) fetch(req *http.Request, key string, entry *cacheEntry) (*http.Response, error) {
+	defer close(entry.ready)
+
+	res, err := t.base.RoundTrip(req)
+	if err != nil || res.StatusCode != http.StatusOK {
+		t.drop(key)
+		return res, err
+	}
+
+	body, err := io.ReadAll(res.Body)
+	res.Body.Close()
+	if err != nil {
+		t.drop(key)
+		return nil, err
+	}
+	entry.ok = true
+	entry.status, entry.proto, entry.header, entry.body = res.Status, res.Proto, res.Header, body
+	return entry.response(req), nil
+}
+
+// drop removes an entry whose response can't be cached.
+func (t *cacheTransport) drop(key string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	delete(t.entries, key)
+}
+
+// response builds a fresh response for req from a cached entry.
+func (e *cacheEntry) response(req *http.Request) *http.Response {
+	return &http.Response{
+		Status:        e.status,
+		StatusCode:    http.StatusOK,
+		Proto:         e.proto,
+		Header:        e.header.Clone(),
+		Body:          io.NopCloser(bytes.NewReader(e.body)),
+		ContentLength: int64(len(e.body)),
+		Request:       req,
+	}
+}
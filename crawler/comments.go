@@ -0,0 +1,65 @@
+package crawler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// commentsURL returns the JSON comments endpoint for a page: the href (or data-url)
+// of the first element matching CommentsSelector, falling back to CommentsURLTemplate
+// with {url} replaced by the escaped page URL and {path} by its path. It returns ""
+// when neither yields an endpoint.
+func commentsURL(doc *goquery.Document, pageURL string, opts Options) string {
+	base, err := url.Parse(pageURL)
+	if err != nil {
+		return ""
+	}
+
+	if opts.CommentsSelector != "" {
+		selection := doc.Find(opts.CommentsSelector).First()
+		for _, attr := range []string{"href", "data-url"} {
+			if href, ok := selection.Attr(attr); ok && strings.TrimSpace(href) != "" {
+				if ref, err := url.Parse(strings.TrimSpace(href)); err == nil {
+					return base.ResolveReference(ref).String()
+				}
+			}
+		}
+	}
+
+	if opts.CommentsURLTemplate != "" {
+		replacer := strings.NewReplacer(
+			"{url}", url.QueryEscape(pageURL),
+			"{path}", base.EscapedPath(),
+		)
+		return replacer.Replace(opts.CommentsURLTemplate)
+	}
+
+	return ""
+}
+
+// fetchComments fetches a comments endpoint and decodes its JSON response as is.
+func fetchComments(ctx context.Context, commentsURL string) (any, error) {
+	res, err := get(ctx, commentsURL)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching comments %s: %w", commentsURL, err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected HTTP status fetching comments %s: %d", commentsURL, res.StatusCode)
+	}
+
+	var comments any
+	decoder := json.NewDecoder(res.Body)
+	decoder.UseNumber() // Keep comment IDs and counts exactly as sent
+	if err := decoder.Decode(&comments); err != nil {
+		return nil, fmt.Errorf("error decoding comments from %s: %w", commentsURL, err)
+	}
+	return comments, nil
+}
@@ -1,148 +1,811 @@
 package crawler
 
 import (
+	"context"
 	"encoding/xml"
+	"errors"
 	"fmt"
 	"html"
+	"io"
 	"net/http"
 	"net/url"
 	"regexp"
+	"sitemapExport/feed"
 	"sitemapExport/html2text"
 	"strings"
+	"time"
 
 	md "github.com/JohannesKaufmann/html-to-markdown"
 	"github.com/JohannesKaufmann/html-to-markdown/plugin"
 	"github.com/PuerkitoBio/goquery"
 	"github.com/kennygrant/sanitize"
 	"github.com/schollz/progressbar/v3"
+	"golang.org/x/text/unicode/norm"
 )
 
-// RSSItem represents an RSS item with relevant fields.
+// RSSItem represents an RSS item with relevant fields. Media elements use the
+// Media RSS namespace (http://search.yahoo.com/mrss/).
 type RSSItem struct {
-	Title       string `xml:"title"`
-	Link        string `xml:"link"`
-	Description string `xml:"description"`
+	Title          string           `xml:"title"`
+	Link           string           `xml:"link"`
+	Description    string           `xml:"description"`
+	ContentEncoded string           `xml:"http://purl.org/rss/1.0/modules/content/ encoded"`
+	PubDate        string           `xml:"pubDate"`
+	Enclosure      RSSEnclosure     `xml:"enclosure"`
+	MediaContent   []MediaContent   `xml:"http://search.yahoo.com/mrss/ content"`
+	MediaThumbnail []MediaThumbnail `xml:"http://search.yahoo.com/mrss/ thumbnail"`
+}
+
+// RSSEnclosure is an item's attached file, such as a podcast episode.
+type RSSEnclosure struct {
+	URL  string `xml:"url,attr"`
+	Type string `xml:"type,attr"`
+}
+
+// MediaContent is a <media:content> element, which may carry its own thumbnails.
+type MediaContent struct {
+	URL        string           `xml:"url,attr"`
+	Type       string           `xml:"type,attr"`
+	Medium     string           `xml:"medium,attr"`
+	Thumbnails []MediaThumbnail `xml:"http://search.yahoo.com/mrss/ thumbnail"`
+}
+
+// MediaThumbnail is a <media:thumbnail> element.
+type MediaThumbnail struct {
+	URL string `xml:"url,attr"`
+}
+
+// image returns the item's thumbnail: a <media:thumbnail>, then an image
+// <media:content> or enclosure. It returns "" when the item has none.
+func (item RSSItem) image() string {
+	for _, thumbnail := range item.MediaThumbnail {
+		if thumbnail.URL != "" {
+			return thumbnail.URL
+		}
+	}
+	for _, content := range item.MediaContent {
+		for _, thumbnail := range content.Thumbnails {
+			if thumbnail.URL != "" {
+				return thumbnail.URL
+			}
+		}
+	}
+	for _, content := range item.MediaContent {
+		if content.URL != "" && (content.Medium == "image" || strings.HasPrefix(content.Type, "image/")) {
+			return content.URL
+		}
+	}
+	if strings.HasPrefix(item.Enclosure.Type, "image/") {
+		return item.Enclosure.URL
+	}
+	return ""
+}
+
+// enclosure returns the item's attached media file: its <enclosure>, then the first
+// non-image <media:content>. It returns "" when the item has none.
+func (item RSSItem) enclosure() string {
+	if item.Enclosure.URL != "" && !strings.HasPrefix(item.Enclosure.Type, "image/") {
+		return item.Enclosure.URL
+	}
+	for _, content := range item.MediaContent {
+		if content.URL != "" && content.Medium != "image" && !strings.HasPrefix(content.Type, "image/") {
+			return content.URL
+		}
+	}
+	return ""
 }
 
 // RSSFeed represents the structure of an RSS feed.
 type RSSFeed struct {
-	Items []RSSItem `xml:"channel>item"`
+	Items []RSSItem  `xml:"channel>item"`
+	Links []atomLink `xml:"channel>link"` // The channel's <atom:link> elements, among its plain <link>
+}
+
+// Sitemap represents the structure of a sitemap (<urlset>) or sitemap index (<sitemapindex>).
+type Sitemap struct {
+	URLs     []SitemapURL `xml:"url"`
+	Sitemaps []SitemapURL `xml:"sitemap"`
+}
+
+// SitemapURL represents a page (or child sitemap) entry in a sitemap.
+type SitemapURL struct {
+	Loc        string      `xml:"loc"`
+	LastMod    string      `xml:"lastmod"`
+	Alternates []Alternate `xml:"http://www.w3.org/1999/xhtml link"`
+}
+
+// Alternate is a language variant of a page, declared with <xhtml:link rel="alternate" hreflang="...">.
+type Alternate struct {
+	HrefLang string `xml:"hreflang,attr" json:"HrefLang"`
+	Href     string `xml:"href,attr" json:"Href"`
 }
 
 // Page represents the extracted data for a single page.
 type Page struct {
-	Title       string   `json:"Title"`
-	URL         string   `json:"URL"`
-	Description string   `json:"Description,omitempty"`
-	Tags        []string `json:"Tags,omitempty"`
-	Content     string   `json:"Content"`
+	Title       string            `json:"Title"`
+	RawTitle    string            `json:"RawTitle,omitempty"` // Title before its site suffix was stripped, if it was
+	URL         string            `json:"URL"`
+	FetchedURL  string            `json:"FetchedURL,omitempty"` // URL actually crawled, when URL was rewritten to the canonical one
+	Description string            `json:"Description,omitempty"`
+	Tags        []string          `json:"Tags,omitempty"`
+	Language    string            `json:"Language,omitempty"`
+	Date        string            `json:"Date,omitempty"`      // The feed's lastmod or pubDate, else the page's JSON-LD datePublished, as RFC 3339 when parseable
+	Image       string            `json:"Image,omitempty"`     // Thumbnail from the RSS item's media elements, else the page's og:image or twitter:image
+	Enclosure   string            `json:"Enclosure,omitempty"` // Media file attached to the RSS item
+	Source      string            `json:"Source,omitempty"`    // Feed the page was listed in, when crawling an OPML subscription list
+	Alternates  []Alternate       `json:"Alternates,omitempty"`
+	Fields      map[string]string `json:"Fields,omitempty"`
+	Links       []string          `json:"Links,omitempty"` // Outbound links in the extracted content, if requested
+	HTTP        *HTTPMeta         `json:"HTTP,omitempty"`
+	Comments    any               `json:"Comments,omitempty"` // Decoded JSON from the page's comments endpoint, if requested
+	Content     string            `json:"Content"`
+	RawHTML     string            `json:"RawHTML,omitempty"` // Original response body, if requested
+	Status      string            `json:"Status,omitempty"`  // StatusError for pages that failed to extract
+	Error       string            `json:"Error,omitempty"`
+}
+
+// StatusError marks a page recorded in place of a URL that failed to extract.
+const StatusError = "error"
+
+// HTTPMeta describes the HTTP response a page was extracted from.
+type HTTPMeta struct {
+	StatusCode     int    `json:"StatusCode"`
+	FinalURL       string `json:"FinalURL"`       // URL after following redirects
+	ContentLength  int64  `json:"ContentLength"`  // Bytes read from the response body
+	ResponseTimeMs int64  `json:"ResponseTimeMs"` // Time to fetch and parse the response
+}
+
+// countingReader counts the bytes read through it.
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+// Read reads from the underlying reader, adding to the byte count.
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
 }
 
 // List of allowed HTML attributes and tags.
 var allowedAttributes = []string{"href", "src", "size", "width", "alt", "title", "colspan"}
 var allowedTags = []string{"h1", "h2", "h3", "h4", "h5", "h6", "hr", "p", "br", "b", "i", "strong", "em", "ol", "ul", "li", "a", "img", "pre", "code", "blockquote", "tr", "td", "th", "table"}
 
+// Additional tags and attributes kept for GitHub-flavored markdown (strikethrough and task lists).
+var gfmAttributes = []string{"type", "checked"}
+var gfmTags = []string{"del", "s", "strike", "input"}
+
+// keepCodeClasses removes class attributes from the selected content, except the
+// class names on <pre> and <code> elements that match pattern. A <code> element with
+// a language-* class keeps just that one, as markdown conversion reads the whole
+// class as the code block's language.
+func keepCodeClasses(selection *goquery.Selection, pattern *regexp.Regexp) {
+	selection.Find("[class]").AddSelection(selection.Filter("[class]")).Each(func(i int, s *goquery.Selection) {
+		name := goquery.NodeName(s)
+		if name != "pre" && name != "code" {
+			s.RemoveAttr("class")
+			return
+		}
+
+		var kept []string
+		for _, class := range strings.Fields(s.AttrOr("class", "")) {
+			if pattern.MatchString(class) {
+				if name == "code" && strings.HasPrefix(class, "language-") {
+					kept = []string{class}
+					break
+				}
+				kept = append(kept, class)
+			}
+		}
+		if len(kept) == 0 {
+			s.RemoveAttr("class")
+		} else {
+			s.SetAttr("class", strings.Join(kept, " "))
+		}
+	})
+}
+
+// entry is a single page URL discovered in a feed, along with any metadata the feed provided.
+type entry struct {
+	URL         string
+	Title       string // From the feed, used with Options.FeedOnly
+	Content     string // HTML content from the feed, used with Options.FeedOnly
+	Description string
+	Date        string
+	Image       string
+	Enclosure   string
+	Source      string // Feed the entry was listed in, for OPML crawls
+	Alternates  []Alternate
+}
+
 // CrawlSitemap fetches and processes a sitemap to extract page content, showing progress.
-func CrawlSitemap(sitemapURL, cssSelector, format string) ([]Page, error) {
-	var pages []Page
+func CrawlSitemap(ctx context.Context, sitemapURL string, opts Options) ([]Page, error) {
+	entries, _, err := fetchSitemap(ctx, sitemapURL, opts)
+	if err != nil {
+		return nil, err
+	}
 
-	// Fetch the sitemap
-	res, err := http.Get(sitemapURL)
+	if opts.HrefLang != "" {
+		entries = filterHrefLang(entries, opts.HrefLang)
+	}
+
+	return crawlEntries(ctx, entries, opts, "Fetching sitemap pages"), nil
+}
+
+// CrawlSitemapIndex fetches a sitemap index, concurrently fetches each child sitemap
+// (gunzipping them if needed), and extracts the content of every page they list.
+func CrawlSitemapIndex(ctx context.Context, indexURL string, opts Options) ([]Page, error) {
+	_, childURLs, err := fetchSitemap(ctx, indexURL, opts)
 	if err != nil {
-		return nil, fmt.Errorf("failed to fetch sitemap: %w", err)
+		return nil, err
 	}
-	defer res.Body.Close()
 
-	// Parse the XML sitemap
-	doc, err := goquery.NewDocumentFromReader(res.Body)
+	entries := collectSitemaps(ctx, childURLs, map[string]bool{indexURL: true}, opts)
+	if opts.HrefLang != "" {
+		entries = filterHrefLang(entries, opts.HrefLang)
+	}
+
+	return crawlEntries(ctx, entries, opts, "Fetching sitemap pages"), nil
+}
+
+// CrawlRobots reads the Sitemap: lines of a robots.txt file, fetches each listed
+// sitemap or sitemap index, and extracts the content of every page they list.
+func CrawlRobots(ctx context.Context, robotsURL string, opts Options) ([]Page, error) {
+	body, err := openFeed(ctx, robotsURL, opts)
 	if err != nil {
-		return nil, fmt.Errorf("error parsing sitemap: %w", err)
+		return nil, fmt.Errorf("failed to fetch robots.txt: %w", err)
 	}
+	sitemapURLs := parseSitemapDirectives(io.LimitReader(body, maxRobotsBytes))
+	body.Close()
 
-	// Get the number of URLs for the progress bar
-	urls := doc.Find("url loc")
-	totalURLs := urls.Length()
+	if len(sitemapURLs) == 0 {
+		return nil, fmt.Errorf("no Sitemap: lines found in %s", robotsURL)
+	}
 
-	// Initialize the progress bar
-	bar := progressbar.NewOptions(totalURLs, progressbar.OptionSetDescription("Fetching sitemap pages"))
+	entries := collectSitemaps(ctx, sitemapURLs, map[string]bool{robotsURL: true}, opts)
+	if opts.HrefLang != "" {
+		entries = filterHrefLang(entries, opts.HrefLang)
+	}
 
-	// Extract each URL from the sitemap and crawl the page
-	urls.Each(func(i int, s *goquery.Selection) {
-		pageURL := s.Text()
-		page, err := extractPage(pageURL, cssSelector, format)
-		if err != nil {
-			fmt.Printf("Error extracting page %s: %v\n", pageURL, err)
-			bar.Add(1)
-			return
+	return crawlEntries(ctx, entries, opts, "Fetching sitemap pages"), nil
+}
+
+// collectSitemaps concurrently fetches the given sitemaps, following any that are
+// themselves indexes, and returns the page entries they list in order. visited holds
+// sitemaps that must not be fetched again, and pages listed twice are kept once.
+func collectSitemaps(ctx context.Context, sitemapURLs []string, visited map[string]bool, opts Options) []entry {
+	var entries []entry
+	seen := make(map[string]bool)
+
+	// Child sitemaps may themselves be indexes, so keep fetching until none are left
+	for len(sitemapURLs) > 0 {
+		var pending []string
+		for _, sitemapURL := range sitemapURLs {
+			if !visited[sitemapURL] {
+				visited[sitemapURL] = true
+				pending = append(pending, sitemapURL)
+			}
 		}
-		pages = append(pages, page)
-		bar.Add(1) // Increment the progress bar
-	})
 
-	return pages, nil
+		results := make([][]entry, len(pending))
+		nested := make([][]string, len(pending))
+		bar := progressbar.NewOptions(len(pending), progressbar.OptionSetDescription("Fetching child sitemaps"))
+		runPool(ctx, len(pending), opts.Concurrency, func(i int) {
+			defer bar.Add(1)
+			pageEntries, children, err := fetchSitemap(ctx, pending[i], opts)
+			if err != nil {
+				fmt.Printf("Error fetching sitemap %s: %v\n", pending[i], err)
+				return
+			}
+			results[i], nested[i] = pageEntries, children
+		})
+		fmt.Print("\n")
+
+		// Aggregate in listed order so output follows the order of the index
+		sitemapURLs = nil
+		for i := range pending {
+			for _, e := range results[i] {
+				if !seen[e.URL] {
+					seen[e.URL] = true
+					entries = append(entries, e)
+				}
+			}
+			sitemapURLs = append(sitemapURLs, nested[i]...)
+		}
+	}
+
+	return entries
 }
 
-// CrawlRSS fetches and processes an RSS feed to extract page content, showing progress.
-func CrawlRSS(rssURL, cssSelector, format string) ([]Page, error) {
-	var pages []Page
+// fetchSitemap fetches a (possibly gzipped) sitemap or sitemap index and returns
+// the page entries and child sitemap URLs it lists.
+func fetchSitemap(ctx context.Context, sitemapURL string, opts Options) ([]entry, []string, error) {
+	// Fetch the sitemap
+	body, err := openFeed(ctx, sitemapURL, opts)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to fetch sitemap: %w", err)
+	}
+	defer body.Close()
+
+	// Parse the XML sitemap using encoding/xml
+	var sitemap Sitemap
+	decoder := xml.NewDecoder(body)
+	if err := decoder.Decode(&sitemap); err != nil {
+		return nil, nil, fmt.Errorf("error parsing sitemap: %w", err)
+	}
 
-	// Fetch the RSS feed
-	res, err := http.Get(rssURL)
+	var entries []entry
+	for _, u := range sitemap.URLs {
+		if loc := strings.TrimSpace(u.Loc); loc != "" {
+			entries = append(entries, entry{URL: loc, Date: normalizeDate(u.LastMod), Alternates: u.Alternates})
+		}
+	}
+
+	var childURLs []string
+	for _, child := range sitemap.Sitemaps {
+		if loc := strings.TrimSpace(child.Loc); loc != "" {
+			childURLs = append(childURLs, loc)
+		}
+	}
+
+	return entries, childURLs, nil
+}
+
+// openFeed opens a feed from a URL or, for downloaded feeds, a local file path,
+// transparently gunzipping it in either case. Fetches are bounded by MetaTimeout.
+func openFeed(ctx context.Context, location string, opts Options) (io.ReadCloser, error) {
+	if feed.IsLocal(location) {
+		return feed.OpenFile(location)
+	}
+
+	ctx, cancel := metaContext(ctx, opts)
+	res, err := get(ctx, location)
 	if err != nil {
-		return nil, fmt.Errorf("failed to fetch RSS feed: %w", err)
+		cancel()
+		return nil, err
 	}
-	defer res.Body.Close()
+	body, err := feed.NewReader(res.Body)
+	if err != nil {
+		res.Body.Close()
+		cancel()
+		return nil, fmt.Errorf("error reading %s: %w", location, err)
+	}
+	return &feedBody{Reader: body, body: res.Body, cancel: cancel}, nil
+}
+
+// feedBody reads a fetched feed, releasing its timeout once the response is closed.
+type feedBody struct {
+	io.Reader
+	body   io.Closer
+	cancel context.CancelFunc
+}
+
+// Close closes the response body and releases its timeout.
+func (f *feedBody) Close() error {
+	defer f.cancel()
+	return f.body.Close()
+}
+
+// filterHrefLang keeps only entries available in the wanted language. Entries with
+// hreflang alternates are swapped for their variant in that language (or dropped when
+// there is none), so each page is crawled once. Entries without alternates are kept.
+func filterHrefLang(entries []entry, lang string) []entry {
+	var kept []entry
+	seen := make(map[string]bool)
+	for _, e := range entries {
+		target := e.URL
+		if len(e.Alternates) > 0 {
+			target = ""
+			for _, alt := range e.Alternates {
+				if matchesLanguage(normalizeLanguage(alt.HrefLang), lang) {
+					target = alt.Href
+					if alt.Href == e.URL {
+						break // Prefer the listed URL itself when it matches
+					}
+				}
+			}
+		}
 
-	// Parse the RSS feed using encoding/xml
-	var rss RSSFeed
-	decoder := xml.NewDecoder(res.Body)
-	if err := decoder.Decode(&rss); err != nil {
-		return nil, fmt.Errorf("error decoding RSS feed: %w", err)
+		if target == "" {
+			fmt.Printf("Skipping %s (no %q hreflang alternate)\n", e.URL, lang)
+			continue
+		}
+		if seen[target] {
+			continue
+		}
+		seen[target] = true
+		e.URL = target
+		kept = append(kept, e)
 	}
+	return kept
+}
 
-	// Initialize the progress bar
-	totalItems := len(rss.Items)
-	bar := progressbar.NewOptions(totalItems, progressbar.OptionSetDescription("Fetching RSS pages"))
+// CrawlRSS fetches and processes an RSS feed to extract page content, showing progress.
+func CrawlRSS(ctx context.Context, rssURL string, opts Options) ([]Page, error) {
+	entries, err := rssEntries(ctx, rssURL, opts)
+	if err != nil {
+		return nil, err
+	}
+	return crawlEntries(ctx, entries, opts, rssDescription(opts)), nil
+}
 
-	// Process each RSS item
-	for _, item := range rss.Items {
+// rssEntries fetches an RSS feed and returns an entry for each of its items,
+// keeping the title, content, and other metadata the feed provides.
+func rssEntries(ctx context.Context, rssURL string, opts Options) ([]entry, error) {
+	// Fetch the RSS feed, and its following pages when they are followed
+	items, err := readRSSPages(ctx, rssURL, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	// Collect each RSS item, keeping the description from the feed. Items repeated
+	// on a later feed page are only kept once.
+	var entries []entry
+	seen := make(map[string]bool)
+	for _, item := range items {
 		if item.Link == "" {
 			fmt.Println("Error: RSS item missing URL. Skipping item.")
-			bar.Add(1)
 			continue
 		}
-		page, err := extractPage(item.Link, cssSelector, format)
+		if seen[item.Link] {
+			continue
+		}
+		seen[item.Link] = true
+		content := item.ContentEncoded
+		if content == "" {
+			content = item.Description
+		}
+		entries = append(entries, entry{
+			URL:         item.Link,
+			Title:       strings.TrimSpace(item.Title),
+			Content:     content,
+			Description: item.Description,
+			Date:        normalizeDate(item.PubDate),
+			Image:       item.image(),
+			Enclosure:   item.enclosure(),
+		})
+	}
+	return entries, nil
+}
+
+// rssDescription is the progress bar description for crawling RSS items.
+func rssDescription(opts Options) string {
+	if opts.FeedOnly {
+		return "Reading RSS items"
+	}
+	return "Fetching RSS pages"
+}
+
+// crawlEntries extracts every entry using the worker pool, showing progress. Pages
+// that fail to extract are reported and skipped; the rest are returned in feed order.
+// If ctx is cancelled, the pages extracted so far are returned.
+func crawlEntries(ctx context.Context, entries []entry, opts Options, description string) []Page {
+	if opts.Resume != nil {
+		entries = skipCompleted(entries, opts.Resume)
+	}
+	if opts.Incremental != nil {
+		entries = skipUnchanged(entries, opts.Incremental)
+	}
+	if opts.OnEntries != nil {
+		opts.OnEntries(len(entries))
+	}
+	if opts.Sample > 0 && len(entries) > opts.Sample {
+		entries = entries[:opts.Sample]
+	}
+
+	if opts.Stats != nil {
+		opts.Stats.queued(len(entries))
+	}
+
+	results := make([]*Page, len(entries))
+	filters := buildFilters(opts)
+	throttle := newThrottle(opts)
+	ctx = withThrottle(ctx, throttle) // For the continuation pages of paginated articles
+	budget := newRetryBudget(opts.RetryBudget)
+
+	// Initialize the progress bar
+	progress := newCrawlProgress(len(entries), description)
+
+	// Duplicate content is resolved in feed order, so the page kept doesn't depend
+	// on which fetch finishes first; hashes[i] is the content hash of entry i's page
+	var deduper *contentDeduper
+	hashes := make([]string, len(entries))
+	if opts.DedupContent {
+		deduper = newContentDeduper()
+	}
+
+	// markCrawled records entry i in the resume and incremental states. It runs only
+	// once the entry's page has been delivered, so a state saved mid-crawl never lists
+	// a URL whose page is still held; crawled[i] is set for entries fetched successfully
+	crawled := make([]bool, len(entries))
+	markCrawled := func(i int) {
+		if !crawled[i] {
+			return
+		}
+		if opts.Resume != nil {
+			opts.Resume.markDone(entries[i].URL)
+		}
+		if opts.Incremental != nil {
+			opts.Incremental.markCrawled(entries[i])
+		}
+	}
+
+	// send hands a finished page to the callback and stream, unless it is a duplicate
+	send := func(i int, page Page) {
+		defer markCrawled(i)
+		if deduper != nil && deduper.duplicate(page.URL, hashes[i]) {
+			return
+		}
+		if opts.OnPage != nil {
+			opts.OnPage(page)
+		}
+		if opts.Stream != nil {
+			opts.Stream <- page
+		}
+	}
+	var ordered *reorderBuffer
+	if (opts.OrderedStream || deduper != nil) && (opts.OnPage != nil || opts.Stream != nil) {
+		ordered = newReorderBuffer(len(entries), send)
+	}
+
+	// emit sends a finished page, holds it for its turn, or keeps it for the returned slice
+	emit := func(i int, page Page) {
+		switch {
+		case ordered != nil:
+			ordered.add(i, page)
+		case opts.OnPage != nil || opts.Stream != nil:
+			send(i, page)
+		default:
+			results[i] = &page
+		}
+	}
+
+	runPool(ctx, len(entries), opts.Concurrency, func(i int) {
+		result := resultCancelled
+		defer func() { progress.done(result) }() // Increment the progress bar
+		if ordered != nil {
+			defer ordered.finish(i)
+		}
+
+		e := entries[i]
+		var page Page
+		var err error
+		if opts.FeedOnly {
+			page, err = feedPage(ctx, e, opts)
+		} else {
+			if err := throttle.wait(ctx, e.URL); err != nil {
+				return
+			}
+			page, err = extractPageRetrying(ctx, e.URL, opts, throttle, budget)
+		}
+		if err == nil && len(opts.PostProcess) > 0 {
+			// Hand the final content, feed items and merged pagination included, to the user's command
+			if page.Content, err = postProcess(ctx, opts.PostProcess, page.Content); err != nil {
+				err = fmt.Errorf("error post-processing %s: %w", e.URL, err)
+			}
+		}
 		if err != nil {
-			fmt.Printf("Error extracting page %s: %v\n", item.Link, err)
-			bar.Add(1)
+			if ctx.Err() != nil {
+				return // Cancelled fetches aren't page failures
+			}
+			if opts.Stats != nil {
+				opts.Stats.done(e.URL, err)
+			}
+			fmt.Printf("Error extracting page %s: %v\n", e.URL, err)
+			result = resultFailed
+			if opts.IncludeErrors {
+				page := Page{URL: e.URL, Status: StatusError, Error: err.Error()}
+				applyEntry(&page, e, opts)
+				emit(i, page)
+			}
+			return
+		}
+
+		result = resultCrawled
+		if opts.Stats != nil {
+			opts.Stats.done(e.URL, nil)
+		}
+		crawled[i] = true
+
+		applyEntry(&page, e, opts)
+
+		if applyFilters(filters, e.URL, &page) {
+			if deduper != nil {
+				hashes[i] = dedupHash(page) // Before the limit, so the full content is compared
+			}
+			if opts.ContentLimit > 0 {
+				page.Content = limitContent(page.Content, opts.ContentLimit, opts.LimitByChars)
+			}
+			emit(i, page)
+		} else {
+			markCrawled(i) // Filtered pages are never written, so there is nothing to wait for
+		}
+	})
+	if ordered != nil {
+		ordered.flush()
+	}
+
+	var pages []Page
+	for i, page := range results {
+		if page == nil {
 			continue
 		}
+		markCrawled(i) // The caller saves the states only after writing the pages
+		if deduper == nil || !deduper.duplicate(page.URL, hashes[i]) {
+			pages = append(pages, *page)
+		}
+	}
+	return pages
+}
+
+// applyEntry fills in a page, failed or not, from what the feed says about its
+// entry, and normalizes its URL.
+func applyEntry(page *Page, e entry, opts Options) {
+	// Set description from the feed when it provides one
+	if e.Description != "" {
+		page.Description = e.Description
+	}
+	page.URL = normalizeLink(page.URL, opts.NormalizeLinks)
+	page.Alternates = e.Alternates
+	if e.Date != "" {
+		page.Date = e.Date // The feed's lastmod or pubDate wins over the page's own date
+	}
+	if e.Image != "" {
+		page.Image = e.Image // The feed's thumbnail wins over the page's sharing image
+	}
+	page.Enclosure = e.Enclosure
+	page.Source = e.Source
+}
+
+// feedPage builds a page from a feed item alone, converting the item's own content
+// instead of fetching the page it links to.
+func feedPage(ctx context.Context, e entry, opts Options) (Page, error) {
+	page := Page{Title: e.Title, URL: e.URL}
+	if opts.NoContent || strings.TrimSpace(e.Content) == "" {
+		return page, nil
+	}
+
+	var err error
+	if opts.SelectorMode == "text" {
+		doc, parseErr := goquery.NewDocumentFromReader(strings.NewReader(e.Content))
+		if parseErr != nil {
+			return Page{}, fmt.Errorf("error parsing feed content: %w", parseErr)
+		}
+		page.Content = strings.TrimSpace(doc.Text())
+	} else {
+		page.Content, err = extractAndTransformContentFromText(ctx, e.Content, opts)
+	}
+	return page, err
+}
 
-		// Set description from the RSS feed
-		page.Description = item.Description
-		pages = append(pages, page)
-		bar.Add(1) // Increment the progress bar
+// skipCompleted drops entries that a previous run already crawled.
+func skipCompleted(entries []entry, state *ResumeState) []entry {
+	var pending []entry
+	for _, e := range entries {
+		if !state.Done(e.URL) {
+			pending = append(pending, e)
+		}
+	}
+	if skipped := len(entries) - len(pending); skipped > 0 {
+		fmt.Printf("Resuming: skipping %d already crawled URLs\n", skipped)
 	}
+	return pending
+}
 
-	return pages, nil
+// openPage fetches a page with fetchPage for extraction, failing on responses with
+// one of RetryStatus codes. Pages declaring a size over MaxContentBytes are refused
+// up front, and the returned body stops reading just past the limit so that
+// checkPageSize can catch the ones that turn out too large. The caller closes the
+// response body.
+func openPage(ctx context.Context, pageURL string, opts Options) (*http.Response, *countingReader, error) {
+	res, err := fetchPage(ctx, pageURL, opts)
+	if err != nil {
+		var loop *redirectLoopError
+		if errors.As(err, &loop) {
+			return nil, nil, fmt.Errorf("redirect loop detected visiting URL %s: %w", pageURL, loop)
+		}
+		return nil, nil, fmt.Errorf("error visiting URL %s: %w", pageURL, err)
+	}
+	if err := retryableStatus(res, opts); err != nil {
+		res.Body.Close()
+		return nil, nil, fmt.Errorf("error visiting URL %s: %w", pageURL, err)
+	}
+
+	var limited io.Reader = res.Body
+	if opts.MaxContentBytes > 0 {
+		if res.ContentLength > opts.MaxContentBytes {
+			res.Body.Close()
+			return nil, nil, fmt.Errorf("page %s is %d bytes, over the %d byte limit", pageURL, res.ContentLength, opts.MaxContentBytes)
+		}
+		limited = io.LimitReader(res.Body, opts.MaxContentBytes+1)
+	}
+	return res, &countingReader{r: limited}, nil
+}
+
+// checkPageSize fails once more of a page's body has been read than MaxContentBytes allows.
+func checkPageSize(body *countingReader, pageURL string, opts Options) error {
+	if opts.MaxContentBytes > 0 && body.n > opts.MaxContentBytes {
+		return fmt.Errorf("page %s is over the %d byte limit", pageURL, opts.MaxContentBytes)
+	}
+	return nil
+}
+
+// extractPage fetches a page and extracts its content based on the CSS selector and format in opts.
+func extractPage(ctx context.Context, pageURL string, opts Options) (Page, error) {
+	return extractPageVisited(ctx, pageURL, opts, map[string]bool{pageURL: true})
 }
 
-// extractPage fetches a page and extracts its content based on a CSS selector and format.
-func extractPage(pageURL, cssSelector, format string) (Page, error) {
-	res, err := http.Get(pageURL)
+// extractPageVisited extracts a page, following AMP pages to their canonical version
+// when requested. visited holds URLs already fetched for this page to avoid loops.
+func extractPageVisited(ctx context.Context, pageURL string, opts Options, visited map[string]bool) (Page, error) {
+	start := time.Now()
+	res, body, err := openPage(ctx, pageURL, opts)
 	if err != nil {
-		return Page{}, fmt.Errorf("error visiting URL %s: %w", pageURL, err)
+		var status *statusError
+		if opts.Stats != nil && errors.As(err, &status) {
+			opts.Stats.response(status.code, 0)
+		}
+		return Page{}, err
 	}
 	defer res.Body.Close()
+	if opts.Stats != nil {
+		defer func() { opts.Stats.response(res.StatusCode, body.n) }()
+	}
+
+	// Keep a copy of the untouched response body when asked to
+	var reader io.Reader = body
+	var rawHTML strings.Builder
+	if opts.IncludeRawHTML {
+		reader = io.TeeReader(body, &rawHTML)
+	}
 
-	doc, err := goquery.NewDocumentFromReader(res.Body)
+	doc, err := goquery.NewDocumentFromReader(reader)
 	if err != nil {
 		return Page{}, fmt.Errorf("error parsing HTML from %s: %w", pageURL, err)
 	}
+	if err := checkPageSize(body, pageURL, opts); err != nil {
+		return Page{}, err
+	}
+
+	// Swap AMP pages for their fuller canonical version when asked to
+	if opts.PreferCanonical && isAMP(doc) {
+		if canonical := canonicalURL(doc, pageURL); canonical != "" && !visited[canonical] {
+			visited[canonical] = true
+			return extractPageVisited(ctx, canonical, opts, visited)
+		}
+	}
+
+	// Record the response details before the document is processed further
+	var httpMeta *HTTPMeta
+	if opts.IncludeHTTPMeta {
+		httpMeta = &HTTPMeta{
+			StatusCode:     res.StatusCode,
+			FinalURL:       res.Request.URL.String(),
+			ContentLength:  body.n,
+			ResponseTimeMs: time.Since(start).Milliseconds(),
+		}
+	}
 
-	// Extract page details
+	// Extract page details, falling back to the OpenGraph and Twitter Card tags
 	title := doc.Find("title").Text()
+	if strings.TrimSpace(title) == "" {
+		title = socialMeta(doc, socialTitleTags)
+	}
+	var rawTitle string
+	if opts.StripTitleSuffix {
+		if stripped := stripTitleSuffix(title, opts.TitleSeparators); stripped != title {
+			title, rawTitle = stripped, title
+		}
+	}
 	description, _ := doc.Find("meta[name=description]").Attr("content")
+	if strings.TrimSpace(description) == "" {
+		description = socialMeta(doc, socialDescriptionTags)
+	}
+	var image string
+	if src := socialMeta(doc, socialImageTags); src != "" {
+		image = toAbsoluteURL(pageURL, src)
+	}
 	tags, _ := doc.Find("meta[name=tags]").Attr("content")
 
 	var metaTags []string
@@ -150,31 +813,165 @@ func extractPage(pageURL, cssSelector, format string) (Page, error) {
 		metaTags = strings.Split(tags, ",")
 	}
 
+	// Read structured data before content extraction removes the scripts it lives in
+	date := jsonLDDate(doc)
+
+	// Bring commented-out and template markup into the document so the selector can reach it
+	if opts.ExpandComments {
+		expandComments(doc)
+	}
+
 	// Convert relative URLs to absolute ones
 	if hostDomain, err := getDomainFromURL(pageURL); err == nil {
 		fixRelativeUrls(doc, hostDomain)
 	}
 
-	// Extract and transform content based on format
-	content, err := extractAndTransformContent(doc, cssSelector, format)
-	if err != nil {
-		return Page{}, err
+	// Extract and transform content based on format, unless only metadata is wanted
+	var content string
+	if !opts.NoContent {
+		content, err = extractAndTransformContent(ctx, doc, pageURL, opts)
+		if err != nil {
+			return Page{}, err
+		}
+	}
+
+	var links []string
+	if opts.ExtractLinks {
+		links = contentLinks(doc, pageURL, opts)
+	}
+
+	// Merge the rest of a multi-part article into this page
+	if opts.FollowPagination {
+		for _, part := range followPagination(ctx, doc, pageURL, opts, visited) {
+			content = strings.TrimRight(content, "\n") + "\n\n" + part
+		}
+	}
+
+	// Capture the site's name and icon the first time its host comes up
+	if opts.Sites != nil {
+		opts.Sites.observe(ctx, doc, pageURL, opts)
+	}
+
+	// Attach the page's comments from its JSON endpoint, keeping the page if that fails
+	var comments any
+	if endpoint := commentsURL(doc, pageURL, opts); endpoint != "" {
+		if comments, err = fetchComments(ctx, endpoint); err != nil {
+			fmt.Printf("Error fetching comments for %s: %v\n", pageURL, err)
+		}
+	}
+
+	// Report the page under its canonical URL when asked to, keeping the crawled one
+	outputURL, fetchedURL := pageURL, ""
+	if opts.Canonicalize {
+		if canonical := canonicalURL(doc, pageURL); canonical != "" && canonical != pageURL {
+			outputURL, fetchedURL = canonical, pageURL
+		}
+	}
+
+	// Use the declared language, guessing from the content only when asked to
+	language := detectLanguage(doc)
+	if language == "" && opts.GuessLanguage {
+		language = guessLanguage(content)
 	}
 
 	return Page{
 		Title:       title,
-		URL:         pageURL,
+		RawTitle:    rawTitle,
+		URL:         outputURL,
+		FetchedURL:  fetchedURL,
 		Description: description,
 		Tags:        metaTags,
+		Language:    language,
+		Date:        date,
+		Image:       image,
+		Fields:      extractFields(doc, pageURL, opts),
+		Links:       links,
+		HTTP:        httpMeta,
+		Comments:    comments,
 		Content:     content,
+		RawHTML:     rawHTML.String(),
 	}, nil
 }
 
+// isAMP reports whether the document is an AMP page (<html amp> or <html ⚡>).
+func isAMP(doc *goquery.Document) bool {
+	html := doc.Find("html")
+	_, amp := html.Attr("amp")
+	_, bolt := html.Attr("⚡")
+	return amp || bolt
+}
+
+// canonicalURL returns the absolute URL of the document's <link rel="canonical">, if any.
+func canonicalURL(doc *goquery.Document, pageURL string) string {
+	return linkRelURL(doc, "canonical", pageURL)
+}
+
+// linkRelURL returns the absolute URL of the document's first <link rel="..."> of the given type, if any.
+func linkRelURL(doc *goquery.Document, rel, pageURL string) string {
+	href, exists := doc.Find(fmt.Sprintf(`link[rel="%s"]`, rel)).Attr("href")
+	href = strings.TrimSpace(href)
+	if !exists || href == "" {
+		return ""
+	}
+	return toAbsoluteURL(pageURL, href)
+}
+
+// extractFields collects the text of each of opts.Fields' selectors, or for
+// selectors written as selector@attr, that attribute of the matched element.
+// Selectors that match nothing are omitted, while an attribute missing from the
+// matched element gives an empty field.
+func extractFields(doc *goquery.Document, pageURL string, opts Options) map[string]string {
+	if len(opts.Fields) == 0 {
+		return nil
+	}
+
+	values := make(map[string]string)
+	for name, spec := range opts.Fields {
+		selector, attr := SplitFieldSelector(spec)
+		selection := doc.Find(selector).First()
+		if selection.Length() == 0 {
+			continue
+		}
+		if attr == "" {
+			values[name] = strings.Join(strings.Fields(selection.Text()), " ")
+			continue
+		}
+		value, exists := selection.Attr(attr)
+		if !exists {
+			// Kept empty, unlike a field whose element is missing, which is left out
+			values[name] = ""
+			opts.debugf("Field %s on %s: %s has no %s attribute, leaving it empty\n", name, pageURL, selector, attr)
+			continue
+		}
+		values[name] = strings.TrimSpace(value)
+	}
+	return values
+}
+
+// fieldAttrPattern matches an attribute name after the @ of a field selector.
+var fieldAttrPattern = regexp.MustCompile(`^[A-Za-z_:][-A-Za-z0-9_:.]*$`)
+
+// SplitFieldSelector splits a field selector written as selector@attr, such as
+// "article@data-published", into the CSS selector and attribute name. Selectors
+// without a trailing @attr are returned whole with an empty attribute, so an @
+// inside an attribute value like a[href$="@example.com"] is left alone.
+func SplitFieldSelector(spec string) (selector, attr string) {
+	at := strings.LastIndex(spec, "@")
+	if at < 0 || !fieldAttrPattern.MatchString(spec[at+1:]) {
+		return spec, ""
+	}
+	return strings.TrimSpace(spec[:at]), spec[at+1:]
+}
+
 // fixRelativeUrls converts relative URLs in links and images to absolute URLs.
 func fixRelativeUrls(doc *goquery.Document, hostDomain string) {
 	convertToAbsolute := func(attr, tag string) {
 		doc.Find(tag).Each(func(i int, s *goquery.Selection) {
 			url, exists := s.Attr(attr)
+			if exists && isScriptLink(url) {
+				s.RemoveAttr(attr) // javascript: links lead nowhere once exported
+				return
+			}
 			if exists && isRelativeURL(url) && !isAnchorLink(url) {
 				absoluteURL := toAbsoluteURL(hostDomain, url)
 				s.SetAttr(attr, absoluteURL)
@@ -187,47 +984,151 @@ func fixRelativeUrls(doc *goquery.Document, hostDomain string) {
 }
 
 // extractAndTransformContent extracts content and applies HTML, Markdown, or Text transformations.
-func extractAndTransformContent(doc *goquery.Document, cssSelector, format string) (string, error) {
-	selection := doc.Find(cssSelector)
+// Every element matching the selector is included, unless opts.FirstMatchOnly is set.
+func extractAndTransformContent(ctx context.Context, doc *goquery.Document, pageURL string, opts Options) (string, error) {
+	selector := opts.contentSelector(pageURL)
+	selection := doc.Find(selector)
 	if selection.Length() == 0 {
-		return "", fmt.Errorf("CSS selector %s not found", cssSelector)
+		if opts.IncludeEmpty {
+			return "", nil // Keep the page's metadata even without content
+		}
+		return "", fmt.Errorf("%w: %s", errSelectorNotFound, selector)
 	}
 
-	htmlContent, err := selection.Html()
-	if err != nil {
-		return "", fmt.Errorf("error extracting HTML: %w", err)
+	// Inline scripts and styles are never content, and their text would otherwise
+	// survive sanitization
+	selection.Find("script, style, noscript").Remove()
+
+	// Reduce responsive images to a single <img> the sanitizer keeps
+	resolvePictures(selection, pageURL)
+
+	// Let syntax-highlighting hints on code blocks through the sanitizer
+	if opts.CodeClassPattern != nil {
+		keepCodeClasses(selection, opts.CodeClassPattern)
+	}
+
+	// The sanitizer drops valueless attributes, so give checked boxes an explicit value
+	if opts.Format == "md" && opts.Markdown.GFM {
+		selection.Find("input[checked]").SetAttr("checked", "checked")
+	}
+
+	if opts.FirstMatchOnly {
+		selection = selection.First()
+	} else {
+		// Skip matches nested inside other matches so their content isn't repeated
+		selection = selection.FilterFunction(func(i int, s *goquery.Selection) bool {
+			return s.ParentsFiltered(selector).Length() == 0
+		})
+		if count := selection.Length(); count > 1 {
+			fmt.Printf("Warning: CSS selector %s matched %d elements on %s, combining them (use --first-match-only for just the first)\n", selector, count, pageURL)
+		}
+	}
+
+	// Keep just the wanted section of each match
+	if opts.Section != "" {
+		selection = selectSection(selection, opts.Section)
+		if selection.Length() == 0 {
+			if opts.IncludeEmpty {
+				return "", nil
+			}
+			return "", fmt.Errorf("section %q not found in %s", opts.Section, selector)
+		}
+	}
+
+	// Text mode takes the matches' text as is, skipping sanitization and conversion
+	if opts.SelectorMode == "text" {
+		texts := make([]string, 0, selection.Length())
+		selection.Each(func(i int, s *goquery.Selection) {
+			if text := strings.TrimSpace(s.Text()); text != "" {
+				texts = append(texts, text)
+			}
+		})
+		return strings.Join(texts, "\n\n"), nil
+	}
+
+	// A single match contributes its inner HTML; several matches are combined
+	// with their own tags so each stays a separate block
+	if selection.Length() == 1 {
+		htmlContent, err := selection.Html()
+		if err != nil {
+			return "", fmt.Errorf("error extracting HTML: %w", err)
+		}
+		return extractAndTransformContentFromText(ctx, htmlContent, opts)
+	}
+
+	var htmlContent strings.Builder
+	for _, node := range selection.Nodes {
+		matchHTML, err := goquery.OuterHtml(goquery.NewDocumentFromNode(node).Selection)
+		if err != nil {
+			return "", fmt.Errorf("error extracting HTML: %w", err)
+		}
+		htmlContent.WriteString(matchHTML + "\n")
 	}
 
-	return extractAndTransformContentFromText(htmlContent, format)
+	return extractAndTransformContentFromText(ctx, htmlContent.String(), opts)
 }
 
 // extractAndTransformContentFromText transforms content into HTML, Markdown, or plain text format.
-func extractAndTransformContentFromText(content, format string) (string, error) {
-	decodedContent := html.UnescapeString(content)
-	sanitizedContent, _ := sanitize.HTMLAllowing(decodedContent, allowedTags, allowedAttributes)
+func extractAndTransformContentFromText(ctx context.Context, content string, opts Options) (string, error) {
+	tags, attributes := allowedTags, allowedAttributes
+	if opts.Format == "md" && opts.Markdown.GFM {
+		tags = append(append([]string{}, allowedTags...), gfmTags...)
+		attributes = append(append([]string{}, allowedAttributes...), gfmAttributes...)
+	}
+	if opts.CodeClassPattern != nil {
+		attributes = append(append([]string{}, attributes...), "class")
+	}
+
+	// The sanitizer decodes entities itself, so text like "&amp;amp;" or "&lt;b&gt;"
+	// stays literal. Sources that escape their HTML a second time are decoded first.
+	decodedContent := content
+	if opts.DecodeEntitiesTwice {
+		decodedContent = html.UnescapeString(content)
+	}
+	if opts.NormalizeUnicode {
+		// Compose characters like "é" the same way however the page encoded them
+		decodedContent = norm.NFC.String(decodedContent)
+	}
+	sanitizedContent, _ := sanitize.HTMLAllowing(decodedContent, tags, attributes)
 
 	// Clean up excess newlines
 	sanitizedContent = removeExcessNewlines(sanitizedContent)
 
-	switch format {
+	if opts.EmbedImages != nil {
+		sanitizedContent = opts.EmbedImages.embed(ctx, sanitizedContent)
+	}
+
+	switch opts.Format {
 	case "html":
 		return sanitizedContent, nil
 	case "md":
-		converter := md.NewConverter("", true, nil)
-		converter.Use(plugin.Table())
+		converter := md.NewConverter("", true, &md.Options{
+			HeadingStyle:     opts.Markdown.HeadingStyle,
+			BulletListMarker: opts.Markdown.BulletMarker,
+			CodeBlockStyle:   opts.Markdown.CodeBlockStyle,
+		})
+		if opts.Markdown.GFM {
+			// Tables plus strikethrough and task lists
+			converter.Use(plugin.GitHubFlavored())
+		} else {
+			converter.Use(plugin.Table())
+		}
 		mdContent, err := converter.ConvertString(sanitizedContent)
 		if err != nil {
 			return "", fmt.Errorf("error converting HTML to Markdown: %w", err)
 		}
-		return mdContent, nil
+		if opts.Markdown.TrimBlankLines {
+			mdContent = trimBlankLines(mdContent)
+		}
+		return removeMatchingParagraphs(mdContent, opts.RemoveTextMatching), nil
 	case "txt":
-		textContent, err := html2text.Convert(sanitizedContent)
+		textContent, err := html2text.Convert(sanitizedContent, html2text.Options{TableFormat: opts.TableFormat})
 		if err != nil {
 			return "", fmt.Errorf("error converting HTML to text: %w", err)
 		}
-		return textContent, nil
+		return removeMatchingParagraphs(textContent, opts.RemoveTextMatching), nil
 	default:
-		return "", fmt.Errorf("unsupported format: %s", format)
+		return "", fmt.Errorf("unsupported format: %s", opts.Format)
 	}
 }
 
@@ -243,10 +1144,30 @@ func getDomainFromURL(pageURL string) (string, error) {
 
 // isRelativeURL checks if the provided URL is relative.
 func isRelativeURL(link string) bool {
+	if hasNonWebScheme(link) {
+		return false
+	}
 	u, err := url.Parse(link)
 	return err == nil && !u.IsAbs()
 }
 
+// hasNonWebScheme reports whether the link uses a scheme such as mailto:, tel:, or
+// javascript: that doesn't point to a page and must never be resolved against one.
+func hasNonWebScheme(link string) bool {
+	link = strings.ToLower(strings.TrimSpace(link))
+	for _, scheme := range []string{"mailto:", "tel:", "javascript:", "data:"} {
+		if strings.HasPrefix(link, scheme) {
+			return true
+		}
+	}
+	return false
+}
+
+// isScriptLink reports whether the link runs JavaScript rather than linking anywhere.
+func isScriptLink(link string) bool {
+	return strings.HasPrefix(strings.ToLower(strings.TrimSpace(link)), "javascript:")
+}
+
 // toAbsoluteURL converts a relative URL to an absolute URL.
 func toAbsoluteURL(host, relativeURL string) string {
 	u, err := url.Parse(relativeURL)
@@ -267,17 +1188,83 @@ func isAnchorLink(link string) bool {
 	return len(link) > 0 && link[0] == '#'
 }
 
-// removeExcessNewlines normalizes line breaks and removes unnecessary newlines.
+// removeMatchingParagraphs drops every paragraph (a block of lines between blank
+// lines) that matches any of the patterns, leaving the rest of the content as is.
+func removeMatchingParagraphs(content string, patterns []*regexp.Regexp) string {
+	if len(patterns) == 0 {
+		return content
+	}
+
+	paragraphs := strings.Split(content, "\n\n")
+	kept := paragraphs[:0]
+paragraphs:
+	for _, paragraph := range paragraphs {
+		if strings.TrimSpace(paragraph) != "" {
+			for _, pattern := range patterns {
+				if pattern.MatchString(paragraph) {
+					continue paragraphs
+				}
+			}
+		}
+		kept = append(kept, paragraph)
+	}
+	return strings.Join(kept, "\n\n")
+}
+
+// removeExcessNewlines normalizes line breaks to \n and collapses every run of two or
+// more whitespace characters (spaces, tabs, form feeds, and line breaks, with \r\n
+// counting as one) to a single space. Lone whitespace characters are kept. It works
+// in a single pass over the content, as pages can run to several megabytes.
 func removeExcessNewlines(content string) string {
-	content = strings.ReplaceAll(content, "\r\n", "\n")
-	content = strings.ReplaceAll(content, "\r", "\n")
-	content = collapseSpaces(content)
-	re := regexp.MustCompile(`\n{3,}`)
-	return re.ReplaceAllString(content, "\n\n")
+	var out strings.Builder
+	out.Grow(len(content))
+
+	for i := 0; i < len(content); {
+		c := content[i]
+		if !isCollapsibleSpace(c) {
+			// Copy everything up to the next whitespace character at once
+			end := i + 1
+			for end < len(content) && !isCollapsibleSpace(content[end]) {
+				end++
+			}
+			out.WriteString(content[i:end])
+			i = end
+			continue
+		}
+
+		// Measure the whitespace run, counting \r\n as a single line break
+		first, run := c, 0
+		for i < len(content) && isCollapsibleSpace(content[i]) {
+			if content[i] == '\r' && i+1 < len(content) && content[i+1] == '\n' {
+				i++
+			}
+			i++
+			run++
+		}
+		switch {
+		case run > 1:
+			out.WriteByte(' ')
+		case first == '\r':
+			out.WriteByte('\n')
+		default:
+			out.WriteByte(first)
+		}
+	}
+	return out.String()
 }
 
-// collapseSpaces reduces multiple spaces within text to a single space.
-func collapseSpaces(content string) string {
-	re := regexp.MustCompile(`\s{2,}`)
-	return re.ReplaceAllString(content, " ")
+// isCollapsibleSpace reports whether c is one of the whitespace characters
+// collapsed by removeExcessNewlines: space, \t, \n, \f, or \r.
+func isCollapsibleSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\f' || c == '\r'
+}
+
+// blankLinesPattern matches a run of two or more blank lines, including lines
+// holding only spaces or tabs.
+var blankLinesPattern = regexp.MustCompile(`\n(?:[ \t]*\n){2,}`)
+
+// trimBlankLines collapses runs of blank lines in converted content to a single
+// blank line.
+func trimBlankLines(content string) string {
+	return blankLinesPattern.ReplaceAllString(content, "\n\n")
 }
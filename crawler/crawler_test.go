@@ -0,0 +1,532 @@
+package crawler
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"path"
+	"strings"
+	"testing"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// newTestServer serves files by path, replacing {{server}} in each body with the
+// server's URL, and points Client at it for the rest of the test. Paths that
+// aren't in files answer 404.
+func newTestServer(t *testing.T, files map[string]string) *httptest.Server {
+	t.Helper()
+	var server *httptest.Server
+	server = newTestHandlerServer(t, func(w http.ResponseWriter, r *http.Request) {
+		body, ok := files[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		switch path.Ext(r.URL.Path) {
+		case ".xml":
+			w.Header().Set("Content-Type", "application/xml")
+		case ".html":
+			w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		}
+		w.Write([]byte(strings.ReplaceAll(body, "{{server}}", server.URL)))
+	})
+	return server
+}
+
+// newTestHandlerServer serves handler and points Client at it for the rest of the test.
+func newTestHandlerServer(t *testing.T, handler http.HandlerFunc) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+	useClient(t, server.Client())
+	return server
+}
+
+// useClient points Client at client for the rest of the test.
+func useClient(t *testing.T, client *http.Client) {
+	previous := Client
+	Client = client
+	t.Cleanup(func() { Client = previous })
+}
+
+// siteFixtures is a small site: a sitemap of two articles and a page without the
+// content selector, an RSS feed listing the articles, and a thin page.
+var siteFixtures = map[string]string{
+	"/sitemap.xml": `<?xml version="1.0" encoding="UTF-8"?>
+<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+  <url><loc>{{server}}/first.html</loc><lastmod>2024-01-02</lastmod></url>
+  <url><loc>{{server}}/docs/second.html</loc></url>
+  <url><loc>{{server}}/nomain.html</loc></url>
+</urlset>`,
+	"/feed.xml": `<?xml version="1.0"?>
+<rss version="2.0" xmlns:media="http://search.yahoo.com/mrss/">
+  <channel>
+    <title>Test feed</title>
+    <item>
+      <title>First from feed</title>
+      <link>{{server}}/first.html</link>
+      <description>Feed summary</description>
+      <pubDate>Tue, 02 Jan 2024 10:00:00 GMT</pubDate>
+      <media:thumbnail url="{{server}}/thumb.jpg"/>
+    </item>
+    <item>
+      <title>Second from feed</title>
+      <link>{{server}}/docs/second.html</link>
+    </item>
+  </channel>
+</rss>`,
+	"/first.html": `<html lang="en"><head>
+<title>First Article</title>
+<meta name="description" content="About the first article">
+<meta name="tags" content="go,testing">
+</head><body>
+<nav>Navigation that isn't content</nav>
+<div id="main">
+  <h1>First</h1>
+  <p>Read the <a href="/docs/second.html">second article</a> or <a href="#top">go up</a>.</p>
+  <p>Some more words so this page is long enough.</p>
+  <img src="images/pic.png" alt="A picture">
+  <script>console.log("not content")</script>
+</div>
+</body></html>`,
+	"/docs/second.html": `<html><head><title>Second Article</title></head><body>
+<div id="main"><p>A <a href="../first.html">link back</a> and <a href="https://example.com/x">an external one</a>, with enough words.</p></div>
+</body></html>`,
+	"/nomain.html": `<html><head><title>No Main</title></head><body><p>Nothing selectable.</p></body></html>`,
+	"/thin.html":   `<html><head><title>Thin</title></head><body><div id="main"><p>Too short.</p></div></body></html>`,
+}
+
+// pagesByURL indexes crawled pages by their URL.
+func pagesByURL(pages []Page) map[string]Page {
+	byURL := make(map[string]Page, len(pages))
+	for _, page := range pages {
+		byURL[page.URL] = page
+	}
+	return byURL
+}
+
+func TestCrawlSitemapExtractsPages(t *testing.T) {
+	server := newTestServer(t, siteFixtures)
+
+	pages, err := CrawlSitemap(context.Background(), server.URL+"/sitemap.xml", Options{CSSSelector: "#main", Format: "html", Concurrency: 2})
+	if err != nil {
+		t.Fatalf("CrawlSitemap: %v", err)
+	}
+
+	// The page without #main fails to extract and is dropped; the rest keep sitemap order
+	if len(pages) != 2 || pages[0].URL != server.URL+"/first.html" || pages[1].URL != server.URL+"/docs/second.html" {
+		t.Fatalf("pages = %+v, want first.html then docs/second.html", pages)
+	}
+
+	first := pages[0]
+	if first.Title != "First Article" {
+		t.Errorf("Title = %q, want %q", first.Title, "First Article")
+	}
+	if first.Description != "About the first article" {
+		t.Errorf("Description = %q", first.Description)
+	}
+	if strings.Join(first.Tags, ",") != "go,testing" {
+		t.Errorf("Tags = %q, want [go testing]", first.Tags)
+	}
+	if first.Language != "en" {
+		t.Errorf("Language = %q, want en", first.Language)
+	}
+	if !strings.HasPrefix(first.Date, "2024-01-02") {
+		t.Errorf("Date = %q, want the sitemap's lastmod 2024-01-02", first.Date)
+	}
+	if strings.Contains(first.Content, "Navigation") || strings.Contains(first.Content, "console.log") {
+		t.Errorf("Content has text from outside the selector or from a script: %q", first.Content)
+	}
+}
+
+func TestCrawlSitemapFixesRelativeURLs(t *testing.T) {
+	server := newTestServer(t, siteFixtures)
+
+	pages, err := CrawlSitemap(context.Background(), server.URL+"/sitemap.xml", Options{CSSSelector: "#main", Format: "html"})
+	if err != nil {
+		t.Fatalf("CrawlSitemap: %v", err)
+	}
+	byURL := pagesByURL(pages)
+
+	first := byURL[server.URL+"/first.html"].Content
+	for _, want := range []string{
+		`href="` + server.URL + `/docs/second.html"`, // Root-relative link
+		`href="#top"`, // In-page anchors stay as they are
+		`src="` + server.URL + `/images/pic.png"`, // Relative image
+	} {
+		if !strings.Contains(first, want) {
+			t.Errorf("first.html content is missing %s:\n%s", want, first)
+		}
+	}
+
+	second := byURL[server.URL+"/docs/second.html"].Content
+	for _, want := range []string{`href="` + server.URL + `/first.html"`, `href="https://example.com/x"`} {
+		if !strings.Contains(second, want) {
+			t.Errorf("docs/second.html content is missing %s:\n%s", want, second)
+		}
+	}
+}
+
+func TestCrawlRSSKeepsFeedMetadata(t *testing.T) {
+	server := newTestServer(t, siteFixtures)
+
+	pages, err := CrawlRSS(context.Background(), server.URL+"/feed.xml", Options{CSSSelector: "#main", Format: "txt"})
+	if err != nil {
+		t.Fatalf("CrawlRSS: %v", err)
+	}
+	if len(pages) != 2 {
+		t.Fatalf("got %d pages, want 2", len(pages))
+	}
+
+	first := pages[0]
+	if first.Title != "First Article" {
+		t.Errorf("Title = %q, want the page's own title", first.Title)
+	}
+	if first.Description != "Feed summary" {
+		t.Errorf("Description = %q, want the feed's description", first.Description)
+	}
+	if !strings.HasPrefix(first.Date, "2024-01-02T10:00:00") {
+		t.Errorf("Date = %q, want the item's pubDate", first.Date)
+	}
+	if first.Image != server.URL+"/thumb.jpg" {
+		t.Errorf("Image = %q, want the media thumbnail", first.Image)
+	}
+	if !strings.Contains(first.Content, "Some more words") {
+		t.Errorf("Content = %q, want the fetched page's text", first.Content)
+	}
+}
+
+func TestCrawlRSSFeedOnly(t *testing.T) {
+	server := newTestServer(t, siteFixtures)
+
+	pages, err := CrawlRSS(context.Background(), server.URL+"/feed.xml", Options{Format: "txt", FeedOnly: true})
+	if err != nil {
+		t.Fatalf("CrawlRSS: %v", err)
+	}
+	if len(pages) != 2 || pages[0].Title != "First from feed" || pages[1].Title != "Second from feed" {
+		t.Fatalf("pages = %+v, want the feed's own titles", pages)
+	}
+	if pages[0].Content != "Feed summary" {
+		t.Errorf("Content = %q, want the item's description", pages[0].Content)
+	}
+}
+
+func TestCrawlSitemapFilters(t *testing.T) {
+	files := map[string]string{
+		"/sitemap.xml": `<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+<url><loc>{{server}}/first.html</loc></url>
+<url><loc>{{server}}/thin.html</loc></url>
+<url><loc>{{server}}/copy.html</loc></url>
+</urlset>`,
+		"/first.html": siteFixtures["/first.html"],
+		"/thin.html":  siteFixtures["/thin.html"],
+		"/copy.html":  siteFixtures["/first.html"],
+	}
+	server := newTestServer(t, files)
+
+	tests := []struct {
+		name string
+		opts Options
+		want []string
+	}{
+		{"no filters", Options{}, []string{"/first.html", "/thin.html", "/copy.html"}},
+		{"min words", Options{MinWords: 5}, []string{"/first.html", "/copy.html"}},
+		{"dedup content", Options{DedupContent: true}, []string{"/first.html", "/thin.html"}},
+		{"custom filter", Options{Filters: []PageFilter{func(url string, page *Page) bool {
+			return !strings.HasSuffix(url, "/first.html")
+		}}}, []string{"/thin.html", "/copy.html"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := tt.opts
+			opts.CSSSelector, opts.Format = "#main", "txt"
+			pages, err := CrawlSitemap(context.Background(), server.URL+"/sitemap.xml", opts)
+			if err != nil {
+				t.Fatalf("CrawlSitemap: %v", err)
+			}
+			var got []string
+			for _, page := range pages {
+				got = append(got, strings.TrimPrefix(page.URL, server.URL))
+			}
+			if strings.Join(got, " ") != strings.Join(tt.want, " ") {
+				t.Errorf("pages = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCrawlSitemapSelectorMatchesNothing(t *testing.T) {
+	server := newTestServer(t, siteFixtures)
+	url := server.URL + "/nomain.html"
+
+	if _, err := extractPage(context.Background(), url, Options{CSSSelector: "#main", Format: "txt"}); err == nil || !strings.Contains(err.Error(), "CSS selector not found") {
+		t.Errorf("extractPage error = %v, want CSS selector not found", err)
+	}
+
+	page, err := extractPage(context.Background(), url, Options{CSSSelector: "#main", Format: "txt", IncludeEmpty: true})
+	if err != nil {
+		t.Fatalf("extractPage with IncludeEmpty: %v", err)
+	}
+	if page.Title != "No Main" || page.Content != "" {
+		t.Errorf("page = %+v, want the title with empty content", page)
+	}
+}
+
+func TestCrawlErrors(t *testing.T) {
+	files := map[string]string{
+		"/broken.xml": `<urlset><url><loc>unterminated`,
+		"/sitemap.xml": `<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+<url><loc>{{server}}/first.html</loc></url>
+<url><loc>{{server}}/missing.html</loc></url>
+</urlset>`,
+		"/first.html": siteFixtures["/first.html"],
+	}
+	server := newTestServer(t, files)
+	ctx := context.Background()
+	opts := Options{CSSSelector: "#main", Format: "txt"}
+
+	if _, err := CrawlSitemap(ctx, server.URL+"/nowhere.xml", opts); err == nil {
+		t.Error("missing sitemap: no error")
+	}
+	if _, err := CrawlSitemap(ctx, "http://127.0.0.1:1/sitemap.xml", opts); err == nil || !strings.Contains(err.Error(), "failed to fetch sitemap") {
+		t.Errorf("unreachable sitemap: error = %v, want failed to fetch sitemap", err)
+	}
+	if _, err := CrawlSitemap(ctx, server.URL+"/broken.xml", opts); err == nil || !strings.Contains(err.Error(), "error parsing sitemap") {
+		t.Errorf("malformed sitemap: error = %v, want error parsing sitemap", err)
+	}
+	if _, err := extractPage(ctx, "http://127.0.0.1:1/page.html", opts); err == nil || !strings.Contains(err.Error(), "error visiting URL") {
+		t.Errorf("unreachable page: error = %v, want error visiting URL", err)
+	}
+
+	// A failed page is dropped, or recorded with its error when asked to
+	pages, err := CrawlSitemap(ctx, server.URL+"/sitemap.xml", opts)
+	if err != nil {
+		t.Fatalf("CrawlSitemap: %v", err)
+	}
+	if len(pages) != 1 || pages[0].URL != server.URL+"/first.html" {
+		t.Errorf("pages = %+v, want only first.html", pages)
+	}
+
+	opts.IncludeErrors = true
+	pages, err = CrawlSitemap(ctx, server.URL+"/sitemap.xml", opts)
+	if err != nil {
+		t.Fatalf("CrawlSitemap: %v", err)
+	}
+	if len(pages) != 2 || pages[1].Status != StatusError || pages[1].Error == "" {
+		t.Errorf("pages = %+v, want missing.html recorded with its error", pages)
+	}
+}
+
+func TestIncludeErrorsNormalizesURL(t *testing.T) {
+	server := newTestServer(t, map[string]string{
+		"/sitemap.xml": `<urlset><url><loc>http://127.0.0.1:1/docs/#top</loc><lastmod>2024-01-02</lastmod></url></urlset>`,
+	})
+
+	opts := Options{CSSSelector: "#main", Format: "txt", IncludeErrors: true, NormalizeLinks: NormalizeStrip}
+	pages, err := CrawlSitemap(context.Background(), server.URL+"/sitemap.xml", opts)
+	if err != nil {
+		t.Fatalf("CrawlSitemap: %v", err)
+	}
+	if len(pages) != 1 || pages[0].Status != StatusError {
+		t.Fatalf("pages = %+v, want the unreachable page recorded with its error", pages)
+	}
+	if pages[0].URL != "http://127.0.0.1:1/docs" {
+		t.Errorf("URL = %q, want it normalized like a crawled page's", pages[0].URL)
+	}
+	if !strings.HasPrefix(pages[0].Date, "2024-01-02") {
+		t.Errorf("Date = %q, want the sitemap's lastmod", pages[0].Date)
+	}
+}
+
+func TestFixRelativeUrlsLeavesNonWebLinks(t *testing.T) {
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(`<body>
+<a id="relative" href="docs/page.html">Docs</a>
+<a id="mailto" href="mailto:team@example.com">Email</a>
+<a id="tel" href="tel:+1-555-0100">Call</a>
+<a id="javascript" href="javascript:void(0)">Menu</a>
+<a id="javascript-caps" href=" JAVASCRIPT:open()">Menu</a>
+<a id="anchor" href="#top">Top</a>
+<img id="data" src="data:image/png;base64,AAAA">
+</body>`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	fixRelativeUrls(doc, "https://example.com")
+
+	tests := []struct {
+		id   string
+		attr string
+		want string // "" for a removed attribute
+	}{
+		{"relative", "href", "https://example.com/docs/page.html"},
+		{"mailto", "href", "mailto:team@example.com"},
+		{"tel", "href", "tel:+1-555-0100"},
+		{"javascript", "href", ""},
+		{"javascript-caps", "href", ""},
+		{"anchor", "href", "#top"},
+		{"data", "src", "data:image/png;base64,AAAA"},
+	}
+	for _, tt := range tests {
+		if got := doc.Find("#"+tt.id).AttrOr(tt.attr, ""); got != tt.want {
+			t.Errorf("%s: %s = %q, want %q", tt.id, tt.attr, got, tt.want)
+		}
+	}
+}
+
+func TestIsRelativeURL(t *testing.T) {
+	tests := map[string]bool{
+		"page.html":                true,
+		"/docs/":                   true,
+		"../up.html":               true,
+		"https://example.com/":     false,
+		"mailto:team@example.com":  false,
+		"MailTo:team@example.com":  false,
+		"tel:+15550100":            false,
+		"javascript:void(0)":       false,
+		" javascript:void(0)":      false,
+		"data:text/plain,hi":       false,
+		"//cdn.example.com/app.js": true, // Protocol-relative, resolved against the page's scheme
+	}
+	for link, want := range tests {
+		if got := isRelativeURL(link); got != want {
+			t.Errorf("isRelativeURL(%q) = %t, want %t", link, got, want)
+		}
+	}
+}
+
+func TestExtractPageNonWebLinks(t *testing.T) {
+	server := newTestServer(t, map[string]string{
+		"/contact.html": `<html><body><div id="main"><ul>
+<li><a href="mailto:team@example.com">Email us</a></li>
+<li><a href="tel:+1-555-0100">Call us</a></li>
+<li><a href="javascript:openChat()">Chat</a></li>
+</ul></div></body></html>`,
+	})
+
+	tests := []struct {
+		format string
+		want   []string
+		absent []string
+	}{
+		{"md", []string{"[Email us](mailto:team@example.com)", "Call us", "Chat"}, []string{"javascript:", server.URL + "/tel:", server.URL + "/mailto:"}},
+		{"txt", []string{"Email us (mailto:team@example.com)", "Chat"}, []string{"javascript:", server.URL}},
+	}
+	for _, tt := range tests {
+		page, err := extractPage(context.Background(), server.URL+"/contact.html", Options{CSSSelector: "#main", Format: tt.format})
+		if err != nil {
+			t.Fatalf("%s: extractPage: %v", tt.format, err)
+		}
+		for _, want := range tt.want {
+			if !strings.Contains(page.Content, want) {
+				t.Errorf("%s: content is missing %q:\n%s", tt.format, want, page.Content)
+			}
+		}
+		for _, absent := range tt.absent {
+			if strings.Contains(page.Content, absent) {
+				t.Errorf("%s: content has %q:\n%s", tt.format, absent, page.Content)
+			}
+		}
+	}
+}
+
+func TestExtractPageDropsScripts(t *testing.T) {
+	server := newTestServer(t, map[string]string{
+		"/page.html": `<html><body><div id="main">
+<p>Visible text.</p>
+<script>var tracking = "inline script";</script>
+<script type="application/ld+json">{"@type": "Article"}</script>
+<style>.hidden { display: none }</style>
+<noscript>Enable JavaScript to continue</noscript>
+<p>More <script>document.write("nested script")</script>text.</p>
+</div></body></html>`,
+	})
+
+	for _, format := range []string{"html", "md", "txt"} {
+		page, err := extractPage(context.Background(), server.URL+"/page.html", Options{CSSSelector: "#main", Format: format})
+		if err != nil {
+			t.Fatalf("%s: extractPage: %v", format, err)
+		}
+		if !strings.Contains(page.Content, "Visible text.") || !strings.Contains(page.Content, "More text.") {
+			t.Errorf("%s: content is missing the page text:\n%s", format, page.Content)
+		}
+		for _, absent := range []string{"inline script", "nested script", "@type", "display: none", "Enable JavaScript"} {
+			if strings.Contains(page.Content, absent) {
+				t.Errorf("%s: content has %q:\n%s", format, absent, page.Content)
+			}
+		}
+	}
+}
+
+func TestEntitiesDecodedOnce(t *testing.T) {
+	content := `<p>Fish &amp; chips, &lt;b&gt;not bold&lt;/b&gt;, &#169; &#x263A; &amp;amp; &eacute;</p>`
+	tests := []struct {
+		format string
+		opts   Options
+		want   string
+	}{
+		// HTML output keeps text escaped, but each entity is only decoded once
+		{"html", Options{}, "<p>Fish &amp; chips, &lt;b&gt;not bold&lt;/b&gt;, © ☺ &amp;amp; é</p>"},
+		{"md", Options{}, "Fish & chips, <b>not bold</b>, © ☺ &amp; é"},
+		{"txt", Options{}, "Fish & chips, <b>not bold</b>, © ☺ &amp; é"},
+		// Content escaped twice over is decoded once more when asked to
+		{"html", Options{DecodeEntitiesTwice: true}, "<p>Fish &amp; chips, <b>not bold</b>, © ☺ &amp; é</p>"},
+		{"md", Options{DecodeEntitiesTwice: true}, "Fish & chips, **not bold**, © ☺ & é"},
+	}
+	for _, tt := range tests {
+		opts := tt.opts
+		opts.Format = tt.format
+		got, err := extractAndTransformContentFromText(context.Background(), content, opts)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.format, err)
+		}
+		if got = strings.TrimSpace(got); got != tt.want {
+			t.Errorf("%s (twice=%t) = %q, want %q", tt.format, opts.DecodeEntitiesTwice, got, tt.want)
+		}
+	}
+}
+
+func TestEntitiesInPageMetadata(t *testing.T) {
+	server := newTestServer(t, map[string]string{
+		"/page.html": `<html><head><title>Q&amp;A &#8211; Help</title><meta name="description" content="Tips &amp; tricks"></head>
+<body><div id="main"><p>R&amp;D &lt;team&gt;</p></div></body></html>`,
+	})
+
+	page, err := extractPage(context.Background(), server.URL+"/page.html", Options{CSSSelector: "#main", Format: "txt"})
+	if err != nil {
+		t.Fatalf("extractPage: %v", err)
+	}
+	if page.Title != "Q&A – Help" || page.Description != "Tips & tricks" {
+		t.Errorf("Title = %q, Description = %q, want their entities decoded", page.Title, page.Description)
+	}
+	if got := strings.TrimSpace(page.Content); got != "R&D <team>" {
+		t.Errorf("Content = %q, want %q", got, "R&D <team>")
+	}
+}
+
+func TestExtractFields(t *testing.T) {
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(`<html><body>
+<p class="byline"> Jane   Doe </p><time>January</time><a href="mailto:jane@example.com">Mail</a>
+</body></html>`))
+	if err != nil {
+		t.Fatalf("parsing document: %v", err)
+	}
+	opts := Options{Fields: map[string]string{
+		"author":   ".byline",
+		"date":     "time@datetime",
+		"missing":  ".nothing@href",
+		"contact":  `a[href$="@example.com"]`,
+		"mailLink": "a@href",
+	}}
+	fields := extractFields(doc, "https://example.com/", opts)
+
+	want := map[string]string{"author": "Jane Doe", "date": "", "contact": "Mail", "mailLink": "mailto:jane@example.com"}
+	for name, value := range want {
+		if got, ok := fields[name]; !ok || got != value {
+			t.Errorf("field %s = %q (present %t), want %q", name, got, ok, value)
+		}
+	}
+	if _, ok := fields["missing"]; ok {
+		t.Errorf("field whose selector matches nothing is present: %q", fields["missing"])
+	}
+}
@@ -0,0 +1,43 @@
+package crawler
+
+import (
+	"strings"
+	"time"
+)
+
+// dateLayouts are the formats seen in sitemap <lastmod> (W3C datetime) and RSS <pubDate> (RFC 822) values.
+var dateLayouts = []string{
+	time.RFC3339,
+	"2006-01-02T15:04Z07:00",
+	"2006-01-02T15:04:05",
+	"2006-01-02",
+	time.RFC1123Z,
+	time.RFC1123,
+	"Mon, 2 Jan 2006 15:04:05 -0700",
+	"Mon, 2 Jan 2006 15:04:05 MST",
+	time.RFC822Z,
+	time.RFC822,
+}
+
+// normalizeDate converts a feed date to RFC 3339 in UTC so dates from different
+// feeds compare as strings. Values that can't be parsed are returned trimmed.
+func normalizeDate(value string) string {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return ""
+	}
+	if t, ok := parseDate(value); ok {
+		return t.UTC().Format(time.RFC3339)
+	}
+	return value
+}
+
+// parseDate parses a trimmed feed date in any of the dateLayouts.
+func parseDate(value string) (time.Time, bool) {
+	for _, layout := range dateLayouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
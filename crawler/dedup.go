@@ -0,0 +1,67 @@
+package crawler
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// contentDeduper remembers the content hash of every page kept during a crawl.
+// Pages are checked in feed order, once they are back in it, so of the pages
+// sharing content the earliest in the feed is kept however the fetches finish.
+// It is safe for concurrent use.
+type contentDeduper struct {
+	mu   sync.Mutex
+	seen map[string]string // content hash -> URL of the page that was kept
+}
+
+// newContentDeduper creates an empty content deduper.
+func newContentDeduper() *contentDeduper {
+	return &contentDeduper{seen: make(map[string]string)}
+}
+
+// check reports whether a page's content hash was already seen. If it was, the
+// URL of the page that was kept is returned; otherwise the page is recorded as
+// kept. Pages without a hash, those with no content, are never duplicates.
+func (d *contentDeduper) check(pageURL, hash string) (string, bool) {
+	if hash == "" {
+		return "", false
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if keptURL, exists := d.seen[hash]; exists {
+		return keptURL, true
+	}
+	d.seen[hash] = pageURL
+	return "", false
+}
+
+// ContentHash returns a SHA-256 hash of the content with whitespace and case normalized.
+func ContentHash(content string) string {
+	normalized := strings.ToLower(strings.Join(strings.Fields(content), " "))
+	sum := sha256.Sum256([]byte(normalized))
+	return hex.EncodeToString(sum[:])
+}
+
+// dedupHash returns the hash a page is deduplicated by, or "" for a page with no
+// content, so that empty pages are neither hashed nor dropped as each other's
+// duplicates.
+func dedupHash(page Page) string {
+	if strings.TrimSpace(page.Content) == "" {
+		return ""
+	}
+	return ContentHash(page.Content)
+}
+
+// duplicate reports whether a page repeats the content of a page already kept,
+// logging the page it skips.
+func (d *contentDeduper) duplicate(pageURL, hash string) bool {
+	keptURL, dup := d.check(pageURL, hash)
+	if dup {
+		fmt.Printf("Skipping duplicate content at %s (kept %s)\n", pageURL, keptURL)
+	}
+	return dup
+}
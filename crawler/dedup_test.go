@@ -0,0 +1,89 @@
+package crawler
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+// dedupServer serves a sitemap of /a.html, /b.html, and /c.html with the same
+// content, where /a.html, first in the sitemap, is the slowest to answer, and
+// /empty1.html and /empty2.html with nothing in their content element.
+func dedupServer(t *testing.T) string {
+	var serverURL string
+	server := newTestHandlerServer(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/sitemap.xml":
+			w.Header().Set("Content-Type", "application/xml")
+			w.Write([]byte(`<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">` +
+				`<url><loc>` + serverURL + `/a.html</loc></url>` +
+				`<url><loc>` + serverURL + `/b.html</loc></url>` +
+				`<url><loc>` + serverURL + `/c.html</loc></url>` +
+				`<url><loc>` + serverURL + `/empty1.html</loc></url>` +
+				`<url><loc>` + serverURL + `/empty2.html</loc></url>` +
+				`</urlset>`))
+		case "/a.html", "/b.html", "/c.html":
+			if r.URL.Path == "/a.html" {
+				time.Sleep(50 * time.Millisecond)
+			}
+			w.Write([]byte(`<html><body><div id="main"><p>Shared content.</p></div></body></html>`))
+		case "/empty1.html", "/empty2.html":
+			w.Write([]byte(`<html><body><div id="main"></div></body></html>`))
+		default:
+			http.NotFound(w, r)
+		}
+	})
+	serverURL = server.URL
+	return serverURL
+}
+
+// pagePaths returns the URL paths of pages, in order.
+func pagePaths(serverURL string, pages []Page) string {
+	var paths []string
+	for _, page := range pages {
+		paths = append(paths, strings.TrimPrefix(page.URL, serverURL))
+	}
+	return strings.Join(paths, " ")
+}
+
+func TestDedupKeepsEarliestInFeed(t *testing.T) {
+	serverURL := dedupServer(t)
+	opts := Options{CSSSelector: "#main", Format: "txt", Concurrency: 5, DedupContent: true, IncludeEmpty: true}
+
+	pages, err := CrawlSitemap(context.Background(), serverURL+"/sitemap.xml", opts)
+	if err != nil {
+		t.Fatalf("CrawlSitemap: %v", err)
+	}
+	// Empty pages aren't duplicates of each other
+	if got, want := pagePaths(serverURL, pages), "/a.html /empty1.html /empty2.html"; got != want {
+		t.Errorf("pages = %s, want %s", got, want)
+	}
+}
+
+func TestDedupStreamKeepsEarliestInFeed(t *testing.T) {
+	serverURL := dedupServer(t)
+	var pages []Page
+	opts := Options{CSSSelector: "#main", Format: "txt", Concurrency: 5, DedupContent: true, IncludeEmpty: true,
+		OnPage: func(page Page) { pages = append(pages, page) }}
+
+	if _, err := CrawlSitemap(context.Background(), serverURL+"/sitemap.xml", opts); err != nil {
+		t.Fatalf("CrawlSitemap: %v", err)
+	}
+	if got, want := pagePaths(serverURL, pages), "/a.html /empty1.html /empty2.html"; got != want {
+		t.Errorf("streamed pages = %s, want %s", got, want)
+	}
+}
+
+func TestContentHashNormalizes(t *testing.T) {
+	if ContentHash("Hello   World\n") != ContentHash("hello world") {
+		t.Error("ContentHash differs for content differing only in case and whitespace")
+	}
+	if ContentHash("hello world") == ContentHash("hello there") {
+		t.Error("ContentHash matches for different content")
+	}
+	if dedupHash(Page{Content: " \n\t"}) != "" {
+		t.Error("dedupHash hashed blank content")
+	}
+}
@@ -0,0 +1,146 @@
+package crawler
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+	"sync"
+
+	"golang.org/x/net/html"
+)
+
+// ImageEmbedder downloads the images referenced by extracted content and inlines
+// them as data: URIs, making each export self-contained. Every image URL is
+// fetched at most once per crawl, however many pages use it.
+type ImageEmbedder struct {
+	maxBytes int64 // Images larger than this are left linked; 0 for no limit
+
+	mu    sync.Mutex
+	cache map[string]string // Image URL to data: URI, or "" if it couldn't be embedded
+}
+
+// NewImageEmbedder creates an embedder that skips images over maxBytes (0 for no limit).
+func NewImageEmbedder(maxBytes int64) *ImageEmbedder {
+	return &ImageEmbedder{maxBytes: maxBytes, cache: make(map[string]string)}
+}
+
+// embed rewrites the src of every <img> in sanitized HTML to a data: URI. It
+// runs after sanitization, which would otherwise strip the data: values, and
+// leaves the rest of the HTML byte for byte as it was.
+func (e *ImageEmbedder) embed(ctx context.Context, content string) string {
+	var out strings.Builder
+	tokenizer := html.NewTokenizer(strings.NewReader(content))
+	for {
+		tokenType := tokenizer.Next()
+		if tokenType == html.ErrorToken {
+			return out.String() // io.EOF on a string reader
+		}
+
+		raw := tokenizer.Raw()
+		if tokenType != html.StartTagToken && tokenType != html.SelfClosingTagToken {
+			out.Write(raw)
+			continue
+		}
+		token := tokenizer.Token()
+		if token.Data != "img" {
+			out.Write(raw)
+			continue
+		}
+
+		embedded := false
+		for i, attr := range token.Attr {
+			if attr.Key == "src" {
+				if dataURI := e.dataURI(ctx, attr.Val); dataURI != "" {
+					token.Attr[i].Val = dataURI
+					embedded = true
+				}
+			}
+		}
+		if embedded {
+			out.WriteString(token.String())
+		} else {
+			out.Write(raw)
+		}
+	}
+}
+
+// dataURI returns the image at imageURL as a data: URI, fetching it the first
+// time it is seen. It returns "" for images that can't be embedded.
+func (e *ImageEmbedder) dataURI(ctx context.Context, imageURL string) string {
+	if !strings.HasPrefix(imageURL, "http://") && !strings.HasPrefix(imageURL, "https://") {
+		return ""
+	}
+
+	e.mu.Lock()
+	dataURI, seen := e.cache[imageURL]
+	e.mu.Unlock()
+	if seen {
+		return dataURI
+	}
+
+	dataURI, err := e.fetch(ctx, imageURL)
+	if err != nil {
+		if ctx.Err() != nil {
+			return "" // Don't remember failures caused by the crawl stopping
+		}
+		fmt.Printf("Skipping image %s: %v\n", imageURL, err)
+	}
+
+	e.mu.Lock()
+	e.cache[imageURL] = dataURI
+	e.mu.Unlock()
+	return dataURI
+}
+
+// fetch downloads an image and encodes it as a data: URI.
+func (e *ImageEmbedder) fetch(ctx context.Context, imageURL string) (string, error) {
+	data, mediaType, err := FetchImage(ctx, imageURL, e.maxBytes)
+	if err != nil {
+		return "", err
+	}
+	return "data:" + mediaType + ";base64," + base64.StdEncoding.EncodeToString(data), nil
+}
+
+// FetchImage downloads the image at imageURL with the shared Client, returning its
+// bytes and media type (from Content-Type, or sniffed when that isn't an image
+// type). Images over maxBytes (0 for no limit) and responses that aren't images
+// are refused.
+func FetchImage(ctx context.Context, imageURL string, maxBytes int64) ([]byte, string, error) {
+	res, err := get(ctx, imageURL)
+	if err != nil {
+		return nil, "", fmt.Errorf("error fetching image: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, "", fmt.Errorf("unexpected HTTP status: %d", res.StatusCode)
+	}
+	if maxBytes > 0 && res.ContentLength > maxBytes {
+		return nil, "", fmt.Errorf("image is %d bytes, over the limit of %d", res.ContentLength, maxBytes)
+	}
+
+	var body io.Reader = res.Body
+	if maxBytes > 0 {
+		body = io.LimitReader(res.Body, maxBytes+1) // Servers may omit or understate Content-Length
+	}
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return nil, "", fmt.Errorf("error reading image: %w", err)
+	}
+	if maxBytes > 0 && int64(len(data)) > maxBytes {
+		return nil, "", fmt.Errorf("image is over the limit of %d bytes", maxBytes)
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(res.Header.Get("Content-Type"))
+	if !strings.HasPrefix(mediaType, "image/") {
+		mediaType = http.DetectContentType(data)
+		if !strings.HasPrefix(mediaType, "image/") {
+			return nil, "", fmt.Errorf("not an image (%s)", mediaType)
+		}
+	}
+	return data, mediaType, nil
+}
@@ -0,0 +1,74 @@
+package crawler
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"strings"
+)
+
+// maxFeedPages caps how many pages of a paginated feed are read.
+const maxFeedPages = 100
+
+// atomLink is an <atom:link> element, which RSS feeds use to point at their
+// other pages (RFC 5005).
+type atomLink struct {
+	Rel  string `xml:"rel,attr"`
+	Href string `xml:"href,attr"`
+}
+
+// nextPage returns the absolute URL of the feed's next page, or "" when it has none.
+func (rss RSSFeed) nextPage(feedURL string) string {
+	for _, link := range rss.Links {
+		if strings.EqualFold(strings.TrimSpace(link.Rel), "next") && strings.TrimSpace(link.Href) != "" {
+			return toAbsoluteURL(feedURL, strings.TrimSpace(link.Href))
+		}
+	}
+	return ""
+}
+
+// readRSSPages fetches an RSS feed and, when FollowFeedPages is set, each page after
+// it linked by <atom:link rel="next">, up to maxFeedPages. visited stops looping
+// chains. A page after the first that fails is reported and ends the chain, keeping
+// the items read so far.
+func readRSSPages(ctx context.Context, rssURL string, opts Options) ([]RSSItem, error) {
+	var items []RSSItem
+	visited := map[string]bool{rssURL: true}
+	for pageURL, pages := rssURL, 0; pageURL != "" && pages < maxFeedPages; pages++ {
+		rss, err := readRSS(ctx, pageURL, opts)
+		if err != nil {
+			if pages == 0 {
+				return nil, err
+			}
+			fmt.Printf("Error following feed page %s: %v\n", pageURL, err)
+			break
+		}
+		items = append(items, rss.Items...)
+
+		if !opts.FollowFeedPages {
+			break
+		}
+		pageURL = rss.nextPage(pageURL)
+		if visited[pageURL] {
+			break
+		}
+		visited[pageURL] = true
+	}
+	return items, nil
+}
+
+// readRSS fetches and decodes a single page of an RSS feed.
+func readRSS(ctx context.Context, rssURL string, opts Options) (RSSFeed, error) {
+	body, err := openFeed(ctx, rssURL, opts)
+	if err != nil {
+		return RSSFeed{}, fmt.Errorf("failed to fetch RSS feed: %w", err)
+	}
+	defer body.Close()
+
+	var rss RSSFeed
+	decoder := xml.NewDecoder(body)
+	if err := decoder.Decode(&rss); err != nil {
+		return RSSFeed{}, fmt.Errorf("error decoding RSS feed: %w", err)
+	}
+	return rss, nil
+}
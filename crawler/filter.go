@@ -0,0 +1,57 @@
+package crawler
+
+import (
+	"fmt"
+	"strings"
+)
+
+// PageFilter decides whether an extracted page is kept, logging its reason when
+// it drops one. Filters are called from the crawl workers, so they must be safe
+// for concurrent use.
+type PageFilter func(url string, page *Page) bool
+
+// buildFilters assembles the filter pipeline for a crawl from opts. Custom filters
+// run after the built-in ones. Deduplication isn't a filter: it runs after all of
+// them, once pages are back in feed order (see contentDeduper).
+func buildFilters(opts Options) []PageFilter {
+	var filters []PageFilter
+	if opts.FilterLanguage != "" {
+		filters = append(filters, languageFilter(opts.FilterLanguage))
+	}
+	if opts.MinWords > 0 {
+		filters = append(filters, minWordsFilter(opts.MinWords))
+	}
+	return append(filters, opts.Filters...)
+}
+
+// applyFilters reports whether the page passes every filter.
+func applyFilters(filters []PageFilter, url string, page *Page) bool {
+	for _, filter := range filters {
+		if !filter(url, page) {
+			return false
+		}
+	}
+	return true
+}
+
+// languageFilter keeps pages in the wanted language.
+func languageFilter(lang string) PageFilter {
+	return func(url string, page *Page) bool {
+		if matchesLanguage(page.Language, lang) {
+			return true
+		}
+		fmt.Printf("Skipping %s (language %q does not match %q)\n", url, page.Language, lang)
+		return false
+	}
+}
+
+// minWordsFilter keeps pages whose content has at least minWords words.
+func minWordsFilter(minWords int) PageFilter {
+	return func(url string, page *Page) bool {
+		if words := len(strings.Fields(page.Content)); words < minWords {
+			fmt.Printf("Skipping %s (%d words, fewer than %d)\n", url, words, minWords)
+			return false
+		}
+		return true
+	}
+}
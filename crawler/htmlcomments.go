@@ -0,0 +1,86 @@
+package crawler
+
+import (
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// expandComments replaces each HTML comment whose text is markup, such as content
+// a framework ships commented out until hydration, with that markup parsed into
+// the document, so CSS selectors can reach it. Other comments are left alone.
+// Markup inside <template> elements is unwrapped too, comments first so that
+// templates found in comments are unwrapped as well.
+func expandComments(doc *goquery.Document) {
+	var comments []*html.Node
+	for _, root := range doc.Nodes {
+		collectComments(root, &comments)
+	}
+
+	for _, comment := range comments {
+		markup := strings.TrimSpace(comment.Data)
+		if !strings.HasPrefix(markup, "<") || comment.Parent == nil {
+			continue
+		}
+
+		context := comment.Parent
+		if context.Type != html.ElementNode {
+			context = &html.Node{Type: html.ElementNode, Data: "body", DataAtom: atom.Body}
+		}
+		nodes, err := html.ParseFragment(strings.NewReader(markup), context)
+		if err != nil {
+			continue
+		}
+		for _, node := range nodes {
+			comment.Parent.InsertBefore(node, comment)
+		}
+		comment.Parent.RemoveChild(comment)
+	}
+
+	unwrapTemplates(doc)
+}
+
+// unwrapTemplates replaces each <template> element with its content, which a
+// page's scripts would otherwise stamp out, so it is extracted like the rest of
+// the page instead of being dropped along with the element.
+func unwrapTemplates(doc *goquery.Document) {
+	var templates []*html.Node
+	for _, root := range doc.Nodes {
+		collectTemplates(root, &templates)
+	}
+
+	// Outer templates come first, so nested ones are unwrapped after they are moved
+	for _, template := range templates {
+		if template.Parent == nil {
+			continue
+		}
+		for child := template.FirstChild; child != nil; child = template.FirstChild {
+			template.RemoveChild(child)
+			template.Parent.InsertBefore(child, template)
+		}
+		template.Parent.RemoveChild(template)
+	}
+}
+
+// collectTemplates appends the <template> elements under node, in document order.
+func collectTemplates(node *html.Node, templates *[]*html.Node) {
+	for child := node.FirstChild; child != nil; child = child.NextSibling {
+		if child.Type == html.ElementNode && child.DataAtom == atom.Template {
+			*templates = append(*templates, child)
+		}
+		collectTemplates(child, templates)
+	}
+}
+
+// collectComments appends the comment nodes under node, in document order.
+func collectComments(node *html.Node, comments *[]*html.Node) {
+	for child := node.FirstChild; child != nil; child = child.NextSibling {
+		if child.Type == html.CommentNode {
+			*comments = append(*comments, child)
+		} else {
+			collectComments(child, comments)
+		}
+	}
+}
@@ -0,0 +1,87 @@
+package crawler
+
+import (
+	"context"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// fixtureFiles serves the named files from testdata at /<name>.
+func fixtureFiles(t *testing.T, names ...string) map[string]string {
+	t.Helper()
+	files := make(map[string]string, len(names))
+	for _, name := range names {
+		data, err := os.ReadFile("testdata/" + name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		files["/"+name] = string(data)
+	}
+	return files
+}
+
+func TestExpandComments(t *testing.T) {
+	server := newTestServer(t, fixtureFiles(t, "comments.html"))
+	url := server.URL + "/comments.html"
+
+	if _, err := extractPage(context.Background(), url, Options{CSSSelector: "#main", Format: "txt"}); err == nil {
+		t.Error("selector matched commented-out markup without ExpandComments")
+	}
+
+	page, err := extractPage(context.Background(), url, Options{CSSSelector: "#main", Format: "txt", ExpandComments: true})
+	if err != nil {
+		t.Fatalf("extractPage: %v", err)
+	}
+	for _, want := range []string{"Shipped in a comment", "Revealed by a script after hydration."} {
+		if !strings.Contains(page.Content, want) {
+			t.Errorf("content is missing %q:\n%s", want, page.Content)
+		}
+	}
+
+	// Markup is parsed in the context of the comment's parent, so table rows stay rows
+	page, err = extractPage(context.Background(), url, Options{CSSSelector: "td", Format: "txt", ExpandComments: true})
+	if err != nil || !strings.Contains(page.Content, "Row in a comment") {
+		t.Errorf("table row in a comment: content = %q, error = %v", page.Content, err)
+	}
+}
+
+func TestExpandCommentsUnwrapsTemplates(t *testing.T) {
+	server := newTestServer(t, fixtureFiles(t, "templates.html"))
+	url := server.URL + "/templates.html"
+
+	for _, format := range []string{"html", "md", "txt"} {
+		page, err := extractPage(context.Background(), url, Options{CSSSelector: "#main", Format: format, ExpandComments: true})
+		if err != nil {
+			t.Fatalf("%s: extractPage: %v", format, err)
+		}
+		for _, want := range []string{"Rendered text.", "Stamped from a template", "Inserted by a script.", "Nested template text.", "Template in a comment."} {
+			if !strings.Contains(page.Content, want) {
+				t.Errorf("%s: content is missing %q:\n%s", format, want, page.Content)
+			}
+		}
+	}
+
+	// The template's content now stands in its place, so selectors reach it directly
+	page, err := extractPage(context.Background(), url, Options{CSSSelector: "#main > h2", Format: "txt", ExpandComments: true})
+	if err != nil || !strings.Contains(page.Content, "Stamped from a template") {
+		t.Errorf("heading from the template: content = %q, error = %v", page.Content, err)
+	}
+}
+
+func TestUnwrapTemplates(t *testing.T) {
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(`<div id="main"><p>1</p><template><p>2</p><template><p>3</p></template></template><p>4</p><template></template></div>`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	unwrapTemplates(doc)
+
+	if n := doc.Find("template").Length(); n != 0 {
+		t.Errorf("%d template elements left", n)
+	}
+	if got, err := doc.Find("#main").Html(); err != nil || got != "<p>1</p><p>2</p><p>3</p><p>4</p>" {
+		t.Errorf("content = %q (%v), want the paragraphs in order", got, err)
+	}
+}
@@ -0,0 +1,167 @@
+package crawler
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// newRedirectServer serves /a and /b redirecting to each other, /chain/N
+// redirecting to /chain/N-1 down to a page at /chain/0, and a sitemap of /a and
+// /chain/3, through a client made by NewClient.
+func newRedirectServer(t *testing.T) string {
+	var serverURL string
+	server := newTestHandlerServer(t, func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/a":
+			http.Redirect(w, r, "/b", http.StatusFound)
+		case r.URL.Path == "/b":
+			http.Redirect(w, r, "/a", http.StatusMovedPermanently)
+		case r.URL.Path == "/chain/0":
+			w.Write([]byte(`<html><head><title>End</title></head><body><div id="main"><p>Arrived.</p></div></body></html>`))
+		case strings.HasPrefix(r.URL.Path, "/chain/"):
+			n, _ := strconv.Atoi(strings.TrimPrefix(r.URL.Path, "/chain/"))
+			http.Redirect(w, r, fmt.Sprintf("/chain/%d", n-1), http.StatusFound)
+		case r.URL.Path == "/sitemap.xml":
+			w.Header().Set("Content-Type", "application/xml")
+			fmt.Fprintf(w, `<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9"><url><loc>%[1]s/a</loc></url><url><loc>%[1]s/chain/3</loc></url></urlset>`, serverURL)
+		default:
+			http.NotFound(w, r)
+		}
+	})
+	serverURL = server.URL
+	useClient(t, NewClient(DefaultClientOptions)) // For its redirect policy
+	return serverURL
+}
+
+func TestRedirectLoop(t *testing.T) {
+	serverURL := newRedirectServer(t)
+	opts := Options{CSSSelector: "#main", Format: "txt"}
+
+	_, err := extractPage(context.Background(), serverURL+"/a", opts)
+	if err == nil || !strings.Contains(err.Error(), "redirect loop detected") {
+		t.Fatalf("error = %v, want a redirect loop", err)
+	}
+	if want := serverURL + "/a -> " + serverURL + "/b -> " + serverURL + "/a"; !strings.Contains(err.Error(), want) {
+		t.Errorf("error = %v, want the chain %s", err, want)
+	}
+}
+
+func TestRedirectChains(t *testing.T) {
+	serverURL := newRedirectServer(t)
+	opts := Options{CSSSelector: "#main", Format: "txt"}
+
+	// As with Go's default policy, the request and its redirects make at most maxRedirects requests
+	page, err := extractPage(context.Background(), serverURL+"/chain/9", opts)
+	if err != nil {
+		t.Fatalf("chain of 9 redirects: %v", err)
+	}
+	if page.Title != "End" {
+		t.Errorf("Title = %q, want the page at the end of the chain", page.Title)
+	}
+
+	_, err = extractPage(context.Background(), serverURL+"/chain/10", opts)
+	if err == nil || !strings.Contains(err.Error(), "stopped after 10 redirects") || strings.Contains(err.Error(), "loop") {
+		t.Errorf("chain of 10 redirects: error = %v, want it stopped without a loop", err)
+	}
+}
+
+func TestRedirectLoopFailsOnlyItsPage(t *testing.T) {
+	serverURL := newRedirectServer(t)
+
+	pages, err := CrawlSitemap(context.Background(), serverURL+"/sitemap.xml", Options{CSSSelector: "#main", Format: "txt", IncludeErrors: true})
+	if err != nil {
+		t.Fatalf("CrawlSitemap: %v", err)
+	}
+	if len(pages) != 2 {
+		t.Fatalf("got %d pages, want 2", len(pages))
+	}
+	if pages[0].Status != StatusError || !strings.Contains(pages[0].Error, "redirect loop detected") {
+		t.Errorf("looping page = %+v, want it recorded as a redirect loop", pages[0])
+	}
+	if pages[1].Status == StatusError || pages[1].Title != "End" {
+		t.Errorf("redirected page = %+v, want it crawled", pages[1])
+	}
+}
+
+func TestProxyAuthorization(t *testing.T) {
+	var proxyAuth, requestURL string
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		proxyAuth = r.Header.Get("Proxy-Authorization")
+		requestURL = r.URL.String()
+		w.Write([]byte(`<html><head><title>Via proxy</title></head><body><div id="main"><p>Proxied.</p></div></body></html>`))
+	}))
+	defer proxy.Close()
+
+	proxyURL, err := url.Parse(proxy.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	proxyURL.User = url.UserPassword("user", "s3cret")
+	useClient(t, NewClient(ClientOptions{Proxy: proxyURL}))
+
+	page, err := extractPage(context.Background(), "http://example.invalid/page.html", Options{CSSSelector: "#main", Format: "txt"})
+	if err != nil {
+		t.Fatalf("extractPage: %v", err)
+	}
+	if page.Title != "Via proxy" {
+		t.Errorf("Title = %q, want the proxy's response", page.Title)
+	}
+	if requestURL != "http://example.invalid/page.html" {
+		t.Errorf("proxy was asked for %q, want the page URL", requestURL)
+	}
+	if want := "Basic " + base64.StdEncoding.EncodeToString([]byte("user:s3cret")); proxyAuth != want {
+		t.Errorf("Proxy-Authorization = %q, want %q", proxyAuth, want)
+	}
+}
+
+func TestProxyErrorsHideCredentials(t *testing.T) {
+	// Nothing listens on the proxy's port, so every request fails
+	proxyURL := &url.URL{Scheme: "http", User: url.UserPassword("user", "s3cret"), Host: "127.0.0.1:1"}
+	useClient(t, NewClient(ClientOptions{Proxy: proxyURL}))
+
+	output := captureStdout(t, func() {
+		pages, err := CrawlSitemap(context.Background(), "http://example.invalid/sitemap.xml", Options{CSSSelector: "#main", Format: "txt", Verbose: true})
+		if err == nil {
+			t.Errorf("crawl through an unreachable proxy returned %d pages and no error", len(pages))
+		} else if strings.Contains(err.Error(), "s3cret") {
+			t.Errorf("error shows the proxy password: %v", err)
+		}
+		_, err = extractPage(context.Background(), "http://example.invalid/page.html", Options{CSSSelector: "#main", Format: "txt"})
+		if err == nil || strings.Contains(err.Error(), "s3cret") {
+			t.Errorf("page error = %v, want one without the proxy password", err)
+		}
+	})
+	if strings.Contains(output, "s3cret") {
+		t.Errorf("output shows the proxy password:\n%s", output)
+	}
+}
+
+// captureStdout returns what f prints to standard output.
+func captureStdout(t *testing.T, f func()) string {
+	t.Helper()
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdout := os.Stdout
+	os.Stdout = w
+	defer func() { os.Stdout = stdout }()
+
+	output := make(chan string)
+	go func() {
+		data, _ := io.ReadAll(r)
+		output <- string(data)
+	}()
+	f()
+	w.Close()
+	return <-output
+}
@@ -0,0 +1,107 @@
+package crawler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+)
+
+// IncrementalState records the lastmod date of each URL crawled, so the next run
+// can skip URLs whose date in the sitemap (or pubDate in the feed) hasn't advanced.
+type IncrementalState struct {
+	path    string
+	mu      sync.Mutex
+	lastMod map[string]string
+}
+
+// incrementalFile is the on-disk format of an incremental state file.
+type incrementalFile struct {
+	LastMod map[string]string `json:"LastMod"`
+}
+
+// LoadIncrementalState reads the incremental state at path. A missing file yields
+// an empty state, so the first run crawls everything.
+func LoadIncrementalState(path string) (*IncrementalState, error) {
+	state := &IncrementalState{path: path, lastMod: make(map[string]string)}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return state, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading incremental state %s: %w", path, err)
+	}
+
+	var file incrementalFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("error parsing incremental state %s: %w", path, err)
+	}
+	for u, date := range file.LastMod {
+		state.lastMod[u] = date
+	}
+	return state, nil
+}
+
+// unchanged reports whether the entry was crawled before with a lastmod at least
+// as recent as its current one. Entries without a date are always crawled.
+func (s *IncrementalState) unchanged(e entry) bool {
+	s.mu.Lock()
+	previous, ok := s.lastMod[e.URL]
+	s.mu.Unlock()
+	if !ok || e.Date == "" {
+		return false
+	}
+
+	current, currentOK := parseDate(e.Date)
+	recorded, recordedOK := parseDate(previous)
+	if !currentOK || !recordedOK {
+		return e.Date == previous
+	}
+	return !current.After(recorded)
+}
+
+// markCrawled records the lastmod the URL was crawled at.
+func (s *IncrementalState) markCrawled(e entry) {
+	if e.Date == "" {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.lastMod[e.URL] = e.Date
+}
+
+// Save writes the state to its file, replacing it atomically. Call it once the
+// crawled pages have been written so the state never runs ahead of the output.
+func (s *IncrementalState) Save() error {
+	s.mu.Lock()
+	data, err := json.MarshalIndent(incrementalFile{LastMod: s.lastMod}, "", "  ")
+	s.mu.Unlock()
+	if err != nil {
+		return fmt.Errorf("failed to marshal incremental state: %w", err)
+	}
+
+	tmpPath := s.path + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0o644); err != nil {
+		return fmt.Errorf("error writing incremental state %s: %w", tmpPath, err)
+	}
+	if err := os.Rename(tmpPath, s.path); err != nil {
+		return fmt.Errorf("error saving incremental state %s: %w", s.path, err)
+	}
+	return nil
+}
+
+// skipUnchanged drops entries whose lastmod hasn't advanced since the last run.
+func skipUnchanged(entries []entry, state *IncrementalState) []entry {
+	var changed []entry
+	for _, e := range entries {
+		if !state.unchanged(e) {
+			changed = append(changed, e)
+		}
+	}
+	if skipped := len(entries) - len(changed); skipped > 0 {
+		fmt.Printf("Incremental: skipping %d URLs unchanged since the last run\n", skipped)
+	}
+	return changed
+}
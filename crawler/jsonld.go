@@ -0,0 +1,46 @@
+package crawler
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// jsonLDDate returns the first datePublished in the page's JSON-LD structured
+// data, normalized like feed dates, or "" if there is none.
+func jsonLDDate(doc *goquery.Document) string {
+	var date string
+	doc.Find(`script[type="application/ld+json"]`).EachWithBreak(func(i int, s *goquery.Selection) bool {
+		var data any
+		if err := json.Unmarshal([]byte(s.Text()), &data); err != nil {
+			return true // Skip malformed blocks rather than failing the page
+		}
+		date = findDatePublished(data)
+		return date == ""
+	})
+	return normalizeDate(date)
+}
+
+// findDatePublished searches JSON-LD for a datePublished value, looking through
+// nested objects, arrays, and @graph lists.
+func findDatePublished(data any) string {
+	switch value := data.(type) {
+	case map[string]any:
+		if date, ok := value["datePublished"].(string); ok && strings.TrimSpace(date) != "" {
+			return date
+		}
+		for _, nested := range value {
+			if date := findDatePublished(nested); date != "" {
+				return date
+			}
+		}
+	case []any:
+		for _, item := range value {
+			if date := findDatePublished(item); date != "" {
+				return date
+			}
+		}
+	}
+	return ""
+}
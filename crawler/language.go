@@ -0,0 +1,77 @@
+package crawler
+
+import (
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// commonWords lists very frequent words for each language the guesser knows about.
+var commonWords = map[string][]string{
+	"en": {"the", "and", "of", "to", "is", "in", "that", "it", "for", "with", "was", "on", "are", "this"},
+	"de": {"der", "die", "und", "das", "ist", "nicht", "mit", "ein", "den", "ich", "zu", "sich", "auf", "für"},
+	"fr": {"le", "la", "les", "et", "des", "est", "une", "dans", "que", "pour", "pas", "qui", "sur", "du"},
+	"es": {"el", "la", "de", "que", "y", "los", "del", "las", "por", "una", "con", "para", "es", "se"},
+	"it": {"il", "di", "che", "e", "la", "per", "un", "non", "sono", "della", "con", "gli", "una", "del"},
+	"nl": {"de", "het", "een", "van", "en", "is", "dat", "niet", "op", "voor", "met", "zijn", "te", "ook"},
+	"pt": {"o", "de", "que", "e", "do", "da", "em", "um", "para", "com", "não", "uma", "os", "no"},
+}
+
+// minGuessWords is the minimum number of recognised words needed before guessing a language.
+const minGuessWords = 5
+
+// detectLanguage returns the page language declared by <html lang> or og:locale,
+// normalized to a lowercase BCP 47 style tag (e.g. "en-us").
+func detectLanguage(doc *goquery.Document) string {
+	if lang, exists := doc.Find("html").Attr("lang"); exists && strings.TrimSpace(lang) != "" {
+		return normalizeLanguage(lang)
+	}
+	if locale, exists := doc.Find(`meta[property="og:locale"]`).Attr("content"); exists && strings.TrimSpace(locale) != "" {
+		return normalizeLanguage(locale)
+	}
+	return ""
+}
+
+// guessLanguage makes a lightweight guess at the language of text by counting very
+// common words for each known language. It returns "" when there is too little evidence.
+func guessLanguage(text string) string {
+	counts := make(map[string]int)
+	for _, word := range strings.Fields(strings.ToLower(text)) {
+		word = strings.Trim(word, ".,;:!?\"'()[]")
+		for lang, words := range commonWords {
+			for _, common := range words {
+				if word == common {
+					counts[lang]++
+					break
+				}
+			}
+		}
+	}
+
+	best, bestCount := "", 0
+	for lang, count := range counts {
+		if count > bestCount || (count == bestCount && lang < best) {
+			best, bestCount = lang, count
+		}
+	}
+	if bestCount < minGuessWords {
+		return ""
+	}
+	return best
+}
+
+// normalizeLanguage lowercases a language tag and uses "-" as the subtag separator.
+func normalizeLanguage(lang string) string {
+	return strings.ReplaceAll(strings.ToLower(strings.TrimSpace(lang)), "_", "-")
+}
+
+// matchesLanguage reports whether lang matches the wanted language. A bare language
+// such as "en" matches any regional variant ("en-us"); a regional tag must match exactly.
+func matchesLanguage(lang, want string) bool {
+	want = normalizeLanguage(want)
+	if strings.Contains(want, "-") {
+		return lang == want
+	}
+	primary, _, _ := strings.Cut(lang, "-")
+	return primary == want
+}
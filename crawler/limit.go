@@ -0,0 +1,58 @@
+package crawler
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// contentEllipsis marks content that was cut short by ContentLimit.
+const contentEllipsis = "..."
+
+// limitContent cuts content after its first limit words, or characters when
+// byChars is set, and appends an ellipsis when anything was cut. The part kept is
+// left as is, so the line breaks and markup of md and txt content survive.
+func limitContent(content string, limit int, byChars bool) string {
+	content = strings.TrimSpace(content)
+	end := len(content)
+	if byChars {
+		if utf8.RuneCountInString(content) > limit {
+			end = runeOffset(content, limit)
+		}
+	} else {
+		end = wordsEnd(content, limit)
+	}
+	if end == len(content) {
+		return content
+	}
+	return strings.TrimRightFunc(content[:end], unicode.IsSpace) + contentEllipsis
+}
+
+// runeOffset returns the byte offset of the nth rune in s.
+func runeOffset(s string, n int) int {
+	for offset := range s {
+		if n == 0 {
+			return offset
+		}
+		n--
+	}
+	return len(s)
+}
+
+// wordsEnd returns the byte offset just past the first n whitespace-separated
+// words of s, or len(s) when s has no more than n words.
+func wordsEnd(s string, n int) int {
+	inWord := false
+	for offset, r := range s {
+		if unicode.IsSpace(r) {
+			if inWord && n == 0 {
+				return offset
+			}
+			inWord = false
+		} else if !inWord {
+			inWord = true
+			n--
+		}
+	}
+	return len(s)
+}
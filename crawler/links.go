@@ -0,0 +1,34 @@
+package crawler
+
+import (
+	"net/url"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// contentLinks returns the absolute http(s) URLs linked from the selected content,
+// deduplicated in the order they first appear. Relative links must already have
+// been made absolute with fixRelativeUrls.
+func contentLinks(doc *goquery.Document, pageURL string, opts Options) []string {
+	selection := doc.Find(opts.contentSelector(pageURL))
+	if opts.FirstMatchOnly {
+		selection = selection.First()
+	}
+
+	var links []string
+	seen := make(map[string]bool)
+	selection.Find("a[href]").Each(func(i int, s *goquery.Selection) {
+		href, _ := s.Attr("href")
+		link, err := url.Parse(strings.TrimSpace(href))
+		if err != nil || (link.Scheme != "http" && link.Scheme != "https") {
+			return // Skip anchors, mailto: links, and the like
+		}
+		link.Fragment = "" // Anchors within a page are the same link
+		if target := normalizeLink(link.String(), opts.NormalizeLinks); !seen[target] {
+			seen[target] = true
+			links = append(links, target)
+		}
+	})
+	return links
+}
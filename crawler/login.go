@@ -0,0 +1,46 @@
+package crawler
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// Login submits a login form once before crawling, so the session cookie it sets
+// is sent with every later request. formData is URL-encoded, e.g.
+// "username=me&password=secret". The shared Client must have a cookie jar.
+func Login(ctx context.Context, loginURL, formData string) error {
+	if Client.Jar == nil {
+		return fmt.Errorf("the HTTP client has no cookie jar to keep the session in")
+	}
+	if _, err := url.ParseQuery(formData); err != nil {
+		return fmt.Errorf("invalid login data (expected name=value&...): %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, loginURL, strings.NewReader(formData))
+	if err != nil {
+		return fmt.Errorf("invalid login URL %s: %w", loginURL, err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	res, err := Client.Do(req)
+	if err != nil {
+		return fmt.Errorf("error submitting login form to %s: %w", loginURL, err)
+	}
+	defer res.Body.Close()
+	io.Copy(io.Discard, res.Body)
+
+	if res.StatusCode >= 400 {
+		return fmt.Errorf("login to %s failed with HTTP status %d %s", loginURL, res.StatusCode, http.StatusText(res.StatusCode))
+	}
+
+	// The session cookie may have been set on a redirect along the way, so look
+	// in the jar rather than only at the final response
+	if len(Client.Jar.Cookies(req.URL)) == 0 && len(Client.Jar.Cookies(res.Request.URL)) == 0 {
+		return fmt.Errorf("login to %s did not set a session cookie; check the form field names in --login-data and the credentials", loginURL)
+	}
+	return nil
+}
@@ -0,0 +1,90 @@
+package crawler
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"strings"
+
+	"github.com/schollz/progressbar/v3"
+)
+
+// opmlDocument is an OPML subscription list, such as an export from a feed or
+// podcast reader.
+type opmlDocument struct {
+	Outlines []opmlOutline `xml:"body>outline"`
+}
+
+// opmlOutline is an OPML entry: a feed when it has an xmlUrl, and possibly a
+// folder of further outlines.
+type opmlOutline struct {
+	XMLURL   string        `xml:"xmlUrl,attr"`
+	Outlines []opmlOutline `xml:"outline"`
+}
+
+// CrawlOPML reads the feeds listed in an OPML file, concurrently fetches each RSS
+// feed, and extracts the pages of all their items, recording on each page the feed
+// it came from in Page.Source. Feeds that fail to load are reported and skipped.
+func CrawlOPML(ctx context.Context, opmlURL string, opts Options) ([]Page, error) {
+	body, err := openFeed(ctx, opmlURL, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch OPML file: %w", err)
+	}
+	var opml opmlDocument
+	err = xml.NewDecoder(body).Decode(&opml)
+	body.Close()
+	if err != nil {
+		return nil, fmt.Errorf("error decoding OPML file: %w", err)
+	}
+
+	feedURLs := opmlFeeds(opml.Outlines, nil, make(map[string]bool))
+	if len(feedURLs) == 0 {
+		return nil, fmt.Errorf("no feeds (outlines with an xmlUrl) found in %s", opmlURL)
+	}
+
+	results := make([][]entry, len(feedURLs))
+	bar := progressbar.NewOptions(len(feedURLs), progressbar.OptionSetDescription("Fetching feeds"))
+	runPool(ctx, len(feedURLs), opts.Concurrency, func(i int) {
+		defer bar.Add(1)
+		feedEntries, err := rssEntries(ctx, feedURLs[i], opts)
+		if err != nil {
+			fmt.Printf("Error fetching feed %s: %v\n", feedURLs[i], err)
+			return
+		}
+		if len(feedEntries) == 0 {
+			fmt.Printf("No RSS items found in %s (only RSS feeds are supported)\n", feedURLs[i])
+		}
+		for j := range feedEntries {
+			feedEntries[j].Source = feedURLs[i]
+		}
+		results[i] = feedEntries
+	})
+	fmt.Print("\n")
+
+	// Merge in listed order, keeping items shared by several feeds once
+	var entries []entry
+	seen := make(map[string]bool)
+	for _, feedEntries := range results {
+		for _, e := range feedEntries {
+			if !seen[e.URL] {
+				seen[e.URL] = true
+				entries = append(entries, e)
+			}
+		}
+	}
+
+	return crawlEntries(ctx, entries, opts, rssDescription(opts)), nil
+}
+
+// opmlFeeds appends the feed URLs of outlines, including those nested in
+// folders, to feeds in document order, skipping any already in seen.
+func opmlFeeds(outlines []opmlOutline, feeds []string, seen map[string]bool) []string {
+	for _, outline := range outlines {
+		if feedURL := strings.TrimSpace(outline.XMLURL); feedURL != "" && !seen[feedURL] {
+			seen[feedURL] = true
+			feeds = append(feeds, feedURL)
+		}
+		feeds = opmlFeeds(outline.Outlines, feeds, seen)
+	}
+	return feeds
+}
@@ -0,0 +1,170 @@
+package crawler
+
+import (
+	"fmt"
+	"regexp"
+	"time"
+)
+
+// Options controls how pages are fetched and extracted during a crawl.
+type Options struct {
+	CSSSelector      string // CSS selector used to extract page content
+	Format           string // Content format transformation (html, md, txt)
+	SelectorMode     string // "text" takes the selector's text as is; otherwise its HTML is sanitized and converted to Format
+	FirstMatchOnly   bool   // Only extract the first element matching CSSSelector instead of all of them
+	ExpandComments   bool   // Parse markup inside HTML comments into the page, and unwrap <template> elements, before applying CSSSelector
+	DedupContent     bool   // Drop pages whose normalized content matches an earlier page in the feed; streamed pages are then sent in feed order
+	NormalizeUnicode bool   // Apply Unicode NFC normalization to extracted content
+	Concurrency      int    // Number of pages (or child sitemaps) fetched at once
+
+	// DecodeEntitiesTwice decodes HTML entities once more before the content is
+	// sanitized, for feeds whose HTML is escaped an extra time (e.g. "&amp;lt;p&amp;gt;").
+	DecodeEntitiesTwice bool
+
+	IncludeHTTPMeta bool   // Record the HTTP status, final URL, size, and timing on each page
+	GuessLanguage   bool   // Guess the language from content when the page doesn't declare one
+	FilterLanguage  string // Only keep pages in this language (e.g. "en"), if set
+	MinWords        int    // Drop pages whose content has fewer words than this
+	Section         string // Keep only the content under the heading with this text, up to the next heading of its level
+	ContentLimit    int    // Cut each page's content after this many words (or characters), if set
+	LimitByChars    bool   // Count ContentLimit in characters instead of words
+	RetryOnEmpty    int    // Refetch pages that come back without content (or under MinWords) up to this many times
+	RetryStatus     []int  // HTTP status codes that are refetched (honoring Retry-After) rather than extracted
+	StatusRetries   int    // How many times a page with one of RetryStatus is refetched before it fails
+	PreferCanonical bool   // Extract AMP pages from their canonical non-AMP URL instead
+	Canonicalize    bool   // Report pages under their <link rel="canonical"> URL, keeping the crawled one in Page.FetchedURL
+	HrefLang        string // Only crawl sitemap URLs (or their hreflang alternates) in this language
+	IncludeErrors   bool   // Record failed URLs as pages with Status and Error set instead of dropping them
+	IncludeEmpty    bool   // Keep pages where CSSSelector matches nothing, with empty Content
+	IncludeRawHTML  bool   // Store the original response body in Page.RawHTML
+	MaxContentBytes int64  // Skip pages whose response body is larger than this, if set
+	ExtractLinks    bool   // Record the absolute links in the extracted content in Page.Links
+	NormalizeLinks  string // Normalize Page.URL and Page.Links with this policy (NormalizeFragments, NormalizeStrip, NormalizeAdd), if set
+
+	FollowPagination bool // Merge pages linked by <link rel="next"> into the first page
+	NoContent        bool // Fetch each page for its metadata only, leaving Page.Content empty
+	FollowFeedPages  bool // Read every page of an RSS feed linked by <atom:link rel="next">
+	FeedOnly         bool // Build RSS pages from each item's own title, link, and content instead of fetching them
+
+	// CommentsSelector selects an element whose href (or data-url) is the page's JSON
+	// comments endpoint. CommentsURLTemplate derives the endpoint from the page URL
+	// instead, replacing {url} with the escaped page URL and {path} with its path.
+	// The decoded response is stored in Page.Comments.
+	CommentsSelector    string
+	CommentsURLTemplate string
+
+	// RetryTimeoutMultiplier, if over 1, grows the request timeout on each retry, so
+	// retry N gets 1+(RetryTimeoutMultiplier-1)×N times the client's timeout.
+	RetryTimeoutMultiplier float64
+
+	// Filters are extra PageFilters applied to every extracted page, after the built-in ones.
+	Filters []PageFilter
+
+	// PostProcess is a command (name and arguments) each page's final content is piped
+	// through, after feed items are converted and pagination is merged.
+	PostProcess []string
+
+	MetaTimeout time.Duration // Timeout for fetching feeds, sitemaps, and robots.txt, if set
+	RetryBudget time.Duration // Cap on the total time spent retrying pages across the crawl, if set
+	Delay       time.Duration // Fixed delay between requests to the same host
+	RobotsDelay bool          // Use each host's robots.txt Crawl-delay instead of Delay
+	Verbose     bool          // Print debug messages
+
+	// Stream, if set, receives each page as soon as it is extracted (in completion
+	// order, unless OrderedStream is set) and the crawl functions return no pages
+	// themselves.
+	Stream chan<- Page
+
+	// OnPage, if set, is called with each page as soon as it is extracted, in place
+	// of collecting pages for the return value, so embedders can index or transform
+	// pages without holding the whole crawl in memory. It is called from the crawling
+	// goroutines, concurrently when Concurrency is above one, so it must be safe for
+	// concurrent use.
+	OnPage func(Page)
+
+	// OrderedStream makes Stream and OnPage receive pages in feed order: a page
+	// extracted early is held until the pages listed before it are done, so output
+	// is deterministic with any Concurrency. OnPage is then never called concurrently.
+	OrderedStream bool
+
+	// Sample, if set, crawls only the first Sample pages found, for estimating a
+	// full crawl. OnEntries, if set, is called with the number of pages found (after
+	// any Resume skips) just before crawling them.
+	Sample    int
+	OnEntries func(total int)
+
+	// EmbedImages, if set, inlines the images in html and md content as data: URIs.
+	EmbedImages *ImageEmbedder
+
+	// Sites, if set, collects the name and favicon of each host crawled, once per host.
+	Sites *SiteCollector
+
+	// PrerenderURL, if set, fetches pages through a prerender service by requesting
+	// PrerenderURL followed by the page URL, falling back to a direct fetch on failure.
+	PrerenderURL string
+
+	// Stats, if set, tallies the pages crawled and failed, the bytes fetched, and
+	// the status codes received.
+	Stats *CrawlStats
+
+	// Resume, if set, skips URLs crawled by a previous run and records newly crawled ones.
+	Resume *ResumeState
+
+	// Incremental, if set, skips URLs whose lastmod hasn't advanced since a previous
+	// run and records the lastmod of newly crawled ones.
+	Incremental *IncrementalState
+
+	// Markdown controls the flavor of markdown produced when Format is "md".
+	Markdown MarkdownOptions
+
+	// StripTitleSuffix trims site branding such as " | Example" from page titles,
+	// cutting at the last of TitleSeparators. The original is kept in Page.RawTitle.
+	StripTitleSuffix bool
+	TitleSeparators  []string
+
+	// SelectorMap overrides CSSSelector for pages on matching hosts.
+	SelectorMap SelectorMap
+
+	// CodeClassPattern, if set, keeps the class names on <pre> and <code> elements
+	// that match it (e.g. language-go), so code blocks keep their language hints.
+	CodeClassPattern *regexp.Regexp
+
+	// RemoveTextMatching drops each paragraph of md or txt content that matches any
+	// of the patterns, for boilerplate such as newsletter prompts that no selector removes.
+	RemoveTextMatching []*regexp.Regexp
+
+	// TableFormat selects the plain text table layout when Format is "txt": "pipe",
+	// "grid", or "csv".
+	TableFormat string
+
+	// Fields maps a custom field name to the CSS selector whose text is stored in Page.Fields,
+	// or to selector@attr to store that attribute of the matched element instead. Fields whose
+	// selector matches nothing are left out; a matched element without the attribute gives "".
+	Fields map[string]string
+}
+
+// MarkdownOptions selects the markdown conventions used for md content.
+// Empty values fall back to the converter defaults.
+type MarkdownOptions struct {
+	HeadingStyle   string // "atx" (# Heading) or "setext" (underlined)
+	BulletMarker   string // "-", "*", or "+"
+	CodeBlockStyle string // "indented" or "fenced"
+	GFM            bool   // Enable GitHub-flavored strikethrough and task lists alongside tables
+	TrimBlankLines bool   // Collapse runs of blank lines in the converted markdown to one
+}
+
+// contentSelector returns the CSS selector used to extract the content of pageURL:
+// the one mapped to its host in SelectorMap, falling back to CSSSelector.
+func (o Options) contentSelector(pageURL string) string {
+	if selector := o.SelectorMap.selectorFor(pageURL); selector != "" {
+		return selector
+	}
+	return o.CSSSelector
+}
+
+// debugf prints a debug message when verbose output is enabled.
+func (o Options) debugf(format string, args ...any) {
+	if o.Verbose {
+		fmt.Printf(format, args...)
+	}
+}
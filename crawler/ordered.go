@@ -0,0 +1,58 @@
+package crawler
+
+import "sync"
+
+// reorderBuffer hands pages extracted concurrently to send in feed order. Each
+// entry's page is held until every entry before it has finished, then the finished
+// prefix is sent, so only pages waiting on a slower earlier entry are kept in
+// memory.
+type reorderBuffer struct {
+	mu       sync.Mutex
+	send     func(int, Page)
+	next     int // Index of the first entry not yet sent
+	finished []bool
+	pending  map[int]Page
+}
+
+// newReorderBuffer creates a buffer for n entries that calls send with each entry's
+// index and page in feed order.
+func newReorderBuffer(n int, send func(int, Page)) *reorderBuffer {
+	return &reorderBuffer{send: send, finished: make([]bool, n), pending: make(map[int]Page)}
+}
+
+// add holds the page extracted for entry i until it is its turn.
+func (b *reorderBuffer) add(i int, page Page) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.pending[i] = page
+}
+
+// finish marks entry i as done, with or without a page, and sends the pages of
+// the entries finished since the last one sent.
+func (b *reorderBuffer) finish(i int) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.finished[i] = true
+	for b.next < len(b.finished) && b.finished[b.next] {
+		b.sendPending(b.next)
+		b.next++
+	}
+}
+
+// flush sends the pages still held, in feed order. Entries skipped when the
+// crawl is cancelled never finish, so the pages after them are only sent here.
+func (b *reorderBuffer) flush() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for ; b.next < len(b.finished); b.next++ {
+		b.sendPending(b.next)
+	}
+}
+
+// sendPending sends the page held for entry i, if there is one.
+func (b *reorderBuffer) sendPending(i int) {
+	if page, ok := b.pending[i]; ok {
+		delete(b.pending, i)
+		b.send(i, page)
+	}
+}
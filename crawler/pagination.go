@@ -0,0 +1,69 @@
+package crawler
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// maxPaginationPages caps how many continuation pages are merged into a single page.
+const maxPaginationPages = 50
+
+// followPagination follows the chain of <link rel="next"> links starting at doc and
+// returns the extracted content of each continuation page, in order. visited holds
+// URLs already fetched for this page so that looping chains stop.
+func followPagination(ctx context.Context, doc *goquery.Document, pageURL string, opts Options, visited map[string]bool) []string {
+	var parts []string
+	for len(parts) < maxPaginationPages {
+		nextURL := linkRelURL(doc, "next", pageURL)
+		if nextURL == "" || visited[nextURL] {
+			break
+		}
+		visited[nextURL] = true
+
+		nextDoc, err := fetchDocument(ctx, nextURL, opts)
+		if err != nil {
+			fmt.Printf("Error following pagination to %s: %v\n", nextURL, err)
+			break
+		}
+		if hostDomain, err := getDomainFromURL(nextURL); err == nil {
+			fixRelativeUrls(nextDoc, hostDomain)
+		}
+
+		content, err := extractAndTransformContent(ctx, nextDoc, nextURL, opts)
+		if err != nil {
+			fmt.Printf("Error extracting paginated page %s: %v\n", nextURL, err)
+			break
+		}
+		parts = append(parts, content)
+		doc, pageURL = nextDoc, nextURL
+	}
+	return parts
+}
+
+// fetchDocument fetches and parses a continuation page the way the page it
+// continues was fetched: through the crawl's throttle, with the prerender service,
+// and within MaxContentBytes. Responses outside 2xx are errors.
+func fetchDocument(ctx context.Context, pageURL string, opts Options) (*goquery.Document, error) {
+	if err := waitThrottle(ctx, pageURL); err != nil {
+		return nil, err
+	}
+	res, body, err := openPage(ctx, pageURL, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return nil, fmt.Errorf("unexpected HTTP status visiting URL %s: %d", pageURL, res.StatusCode)
+	}
+
+	doc, err := goquery.NewDocumentFromReader(body)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing HTML from %s: %w", pageURL, err)
+	}
+	if err := checkPageSize(body, pageURL, opts); err != nil {
+		return nil, err
+	}
+	return doc, nil
+}
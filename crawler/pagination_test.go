@@ -0,0 +1,101 @@
+package crawler
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// paginatedFixtures is an article split over three pages, whose last page is missing.
+var paginatedFixtures = map[string]string{
+	"/article.html":   `<html><head><title>Article</title><link rel="next" href="/article-2.html"></head><body><div id="main"><p>Part one.</p></div></body></html>`,
+	"/article-2.html": `<html><head><link rel="next" href="/article-3.html"></head><body><div id="main"><p>Part two.</p></div></body></html>`,
+}
+
+func TestFollowPagination(t *testing.T) {
+	server := newTestServer(t, paginatedFixtures)
+
+	page, err := extractPage(context.Background(), server.URL+"/article.html", Options{CSSSelector: "#main", Format: "txt", FollowPagination: true})
+	if err != nil {
+		t.Fatalf("extractPage: %v", err)
+	}
+	// The missing third page ends the chain rather than adding its 404 page
+	if strings.TrimSpace(page.Content) != "Part one.\n\nPart two." {
+		t.Errorf("Content = %q, want both parts", page.Content)
+	}
+}
+
+func TestFetchDocumentErrors(t *testing.T) {
+	files := map[string]string{"/big.html": "<html><body>" + strings.Repeat("x", 2000) + "</body></html>"}
+	server := newTestServer(t, files)
+	ctx := context.Background()
+
+	if _, err := fetchDocument(ctx, server.URL+"/missing.html", Options{}); err == nil || !strings.Contains(err.Error(), "404") {
+		t.Errorf("missing page: error = %v, want its 404 status", err)
+	}
+	if _, err := fetchDocument(ctx, server.URL+"/big.html", Options{MaxContentBytes: 100}); err == nil || !strings.Contains(err.Error(), "byte limit") {
+		t.Errorf("oversized page: error = %v, want the byte limit", err)
+	}
+	if _, err := fetchDocument(ctx, server.URL+"/big.html", Options{}); err != nil {
+		t.Errorf("page within limits: %v", err)
+	}
+}
+
+func TestFollowPaginationUsesPrerender(t *testing.T) {
+	var mu sync.Mutex
+	var rendered []string
+	var server string
+	server = newTestHandlerServer(t, func(w http.ResponseWriter, r *http.Request) {
+		target, ok := strings.CutPrefix(r.URL.Path, "/render/")
+		if !ok {
+			http.Error(w, "only rendered pages are served", http.StatusForbidden)
+			return
+		}
+		mu.Lock()
+		rendered = append(rendered, target)
+		mu.Unlock()
+		body, ok := paginatedFixtures[strings.TrimPrefix(target, server)]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(body))
+	}).URL
+
+	opts := Options{CSSSelector: "#main", Format: "txt", FollowPagination: true, PrerenderURL: server + "/render/"}
+	page, err := extractPage(context.Background(), server+"/article.html", opts)
+	if err != nil {
+		t.Fatalf("extractPage: %v", err)
+	}
+	if strings.TrimSpace(page.Content) != "Part one.\n\nPart two." {
+		t.Errorf("Content = %q, want both parts", page.Content)
+	}
+	if len(rendered) < 2 || !strings.HasSuffix(rendered[1], "/article-2.html") {
+		t.Errorf("prerendered %v, want the continuation page among them", rendered)
+	}
+}
+
+func TestFollowPaginationIsThrottled(t *testing.T) {
+	files := map[string]string{"/sitemap.xml": `<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9"><url><loc>{{server}}/article.html</loc></url></urlset>`}
+	for name, body := range paginatedFixtures {
+		files[name] = body
+	}
+	server := newTestServer(t, files)
+
+	delay := 200 * time.Millisecond
+	start := time.Now()
+	pages, err := CrawlSitemap(context.Background(), server.URL+"/sitemap.xml", Options{CSSSelector: "#main", Format: "txt", FollowPagination: true, Delay: delay})
+	if err != nil {
+		t.Fatalf("CrawlSitemap: %v", err)
+	}
+	if len(pages) != 1 || !strings.Contains(pages[0].Content, "Part two.") {
+		t.Fatalf("pages = %+v, want the merged article", pages)
+	}
+	// The article, its second page, and its missing third page are each a delay apart
+	if elapsed := time.Since(start); elapsed < 2*delay {
+		t.Errorf("crawl took %s, want at least %s between three requests to the host", elapsed, 2*delay)
+	}
+}
@@ -0,0 +1,137 @@
+package crawler
+
+import (
+	"html"
+	"strconv"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// srcsetCandidate is one image URL from a srcset attribute, with its width ("800w")
+// or pixel density ("2x") descriptor. Candidates without a descriptor are 1x.
+type srcsetCandidate struct {
+	url     string
+	width   int
+	density float64
+}
+
+// resolvePictures replaces each <picture> in the selection with a single <img> of
+// its most appropriate source, and gives <img> elements that only have a srcset a
+// src, so responsive images survive sanitization. URLs are made absolute against
+// pageURL.
+func resolvePictures(selection *goquery.Selection, pageURL string) {
+	selection.Find("picture").Each(func(i int, picture *goquery.Selection) {
+		img := picture.Find("img").First()
+		src := pictureSource(picture, img)
+		if src == "" {
+			picture.Remove()
+			return
+		}
+
+		src = toAbsoluteURL(pageURL, src)
+		if img.Length() == 0 {
+			picture.ReplaceWithHtml(`<img src="` + html.EscapeString(src) + `" alt="">`)
+			return
+		}
+		img.SetAttr("src", src)
+		img.RemoveAttr("srcset")
+		picture.ReplaceWithSelection(img)
+	})
+
+	selection.Find("img[srcset]").Each(func(i int, img *goquery.Selection) {
+		if strings.TrimSpace(img.AttrOr("src", "")) == "" {
+			if best, ok := largestCandidate(parseSrcset(img.AttrOr("srcset", ""))); ok {
+				img.SetAttr("src", toAbsoluteURL(pageURL, best.url))
+			}
+		}
+	})
+}
+
+// pictureSource picks the image URL for a <picture>: the largest candidate in the
+// srcsets of its <source> elements and fallback <img>, or, when none of them give
+// sizes, the fallback <img> src, then the first candidate listed.
+func pictureSource(picture, img *goquery.Selection) string {
+	var candidates []srcsetCandidate
+	picture.Find("source[srcset]").Each(func(i int, source *goquery.Selection) {
+		candidates = append(candidates, parseSrcset(source.AttrOr("srcset", ""))...)
+	})
+	candidates = append(candidates, parseSrcset(img.AttrOr("srcset", ""))...)
+
+	if best, ok := largestCandidate(candidates); ok && (best.width > 0 || best.density > 1) {
+		return best.url
+	}
+	if src := strings.TrimSpace(img.AttrOr("src", "")); src != "" {
+		return src
+	}
+	if len(candidates) > 0 {
+		return candidates[0].url
+	}
+	return ""
+}
+
+// largestCandidate returns the widest candidate or, among candidates without a
+// width, the densest. The first listed wins ties.
+func largestCandidate(candidates []srcsetCandidate) (srcsetCandidate, bool) {
+	if len(candidates) == 0 {
+		return srcsetCandidate{}, false
+	}
+	best := candidates[0]
+	for _, candidate := range candidates[1:] {
+		if candidate.width > best.width || (candidate.width == best.width && candidate.density > best.density) {
+			best = candidate
+		}
+	}
+	return best, true
+}
+
+// parseSrcset splits a srcset attribute into its candidates. A candidate's URL runs
+// to the next whitespace, so URLs containing commas are kept whole, and invalid
+// descriptors are treated as 1x.
+func parseSrcset(srcset string) []srcsetCandidate {
+	var candidates []srcsetCandidate
+	rest := srcset
+	for {
+		rest = strings.TrimLeft(rest, " \t\n\r\f,")
+		if rest == "" {
+			return candidates
+		}
+
+		end := strings.IndexAny(rest, " \t\n\r\f")
+		if end < 0 {
+			end = len(rest)
+		}
+		candidate := srcsetCandidate{url: rest[:end], density: 1}
+		rest = rest[end:]
+
+		// A URL ending in a comma has no descriptor
+		if trimmed := strings.TrimRight(candidate.url, ","); trimmed != candidate.url {
+			candidate.url = trimmed
+		} else {
+			descriptor := rest
+			if comma := strings.IndexByte(rest, ','); comma >= 0 {
+				descriptor, rest = rest[:comma], rest[comma+1:]
+			} else {
+				rest = ""
+			}
+			applyDescriptor(&candidate, strings.TrimSpace(descriptor))
+		}
+		if candidate.url != "" {
+			candidates = append(candidates, candidate)
+		}
+	}
+}
+
+// applyDescriptor sets a candidate's width or density from its descriptor.
+func applyDescriptor(candidate *srcsetCandidate, descriptor string) {
+	switch {
+	case strings.HasSuffix(descriptor, "w"):
+		if width, err := strconv.Atoi(strings.TrimSuffix(descriptor, "w")); err == nil && width > 0 {
+			candidate.width = width
+		}
+	case strings.HasSuffix(descriptor, "x"):
+		if density, err := strconv.ParseFloat(strings.TrimSuffix(descriptor, "x"), 64); err == nil && density > 0 {
+			candidate.density = density
+		}
+	}
+}
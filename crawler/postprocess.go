@@ -0,0 +1,28 @@
+package crawler
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// postProcess pipes content through an external command (name followed by its
+// arguments) and returns the command's output. A non-zero exit is an error.
+func postProcess(ctx context.Context, command []string, content string) (string, error) {
+	cmd := exec.CommandContext(ctx, command[0], command[1:]...)
+	cmd.Stdin = strings.NewReader(content)
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("%s: %w: %s", command[0], err, msg)
+		}
+		return "", fmt.Errorf("%s: %w", command[0], err)
+	}
+	return stdout.String(), nil
+}
@@ -0,0 +1,50 @@
+package crawler
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+var upperCase = []string{"tr", "a-z", "A-Z"}
+
+func TestPostProcessFeedOnly(t *testing.T) {
+	server := newTestServer(t, siteFixtures)
+
+	pages, err := CrawlRSS(context.Background(), server.URL+"/feed.xml", Options{Format: "txt", FeedOnly: true, PostProcess: upperCase})
+	if err != nil {
+		t.Fatalf("CrawlRSS: %v", err)
+	}
+	if len(pages) == 0 || pages[0].Content != "FEED SUMMARY" {
+		t.Errorf("pages = %+v, want the item's description post-processed", pages)
+	}
+}
+
+func TestPostProcessMergedPagination(t *testing.T) {
+	files := map[string]string{"/sitemap.xml": `<urlset><url><loc>{{server}}/article.html</loc></url></urlset>`}
+	for path, body := range paginatedFixtures {
+		files[path] = body
+	}
+	server := newTestServer(t, files)
+
+	opts := Options{CSSSelector: "#main", Format: "txt", FollowPagination: true, PostProcess: upperCase}
+	pages, err := CrawlSitemap(context.Background(), server.URL+"/sitemap.xml", opts)
+	if err != nil {
+		t.Fatalf("CrawlSitemap: %v", err)
+	}
+	if len(pages) != 1 || strings.TrimSpace(pages[0].Content) != "PART ONE.\n\nPART TWO." {
+		t.Errorf("pages = %+v, want both parts post-processed", pages)
+	}
+}
+
+func TestPostProcessFailureDropsPage(t *testing.T) {
+	server := newTestServer(t, siteFixtures)
+
+	pages, err := CrawlRSS(context.Background(), server.URL+"/feed.xml", Options{Format: "txt", FeedOnly: true, PostProcess: []string{"false"}})
+	if err != nil {
+		t.Fatalf("CrawlRSS: %v", err)
+	}
+	if len(pages) != 0 {
+		t.Errorf("pages = %+v, want none when the command fails", pages)
+	}
+}
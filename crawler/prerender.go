@@ -0,0 +1,31 @@
+package crawler
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+)
+
+// fetchPage fetches a page, through the PrerenderURL service when one is set so
+// that pages built by JavaScript arrive as their rendered HTML. The service is
+// asked for PrerenderURL followed by the page URL; if it fails or answers with
+// an error status, the page is fetched directly instead.
+func fetchPage(ctx context.Context, pageURL string, opts Options) (*http.Response, error) {
+	if opts.PrerenderURL == "" {
+		return get(ctx, pageURL)
+	}
+
+	res, err := get(ctx, opts.PrerenderURL+pageURL)
+	if err == nil && res.StatusCode < http.StatusBadRequest {
+		return res, nil
+	}
+	if ctx.Err() != nil {
+		return nil, err // Don't retry directly once the crawl is stopping
+	}
+	if err == nil {
+		res.Body.Close()
+		err = fmt.Errorf("HTTP status %d", res.StatusCode)
+	}
+	fmt.Printf("Prerendering %s failed (%v), fetching it directly\n", pageURL, err)
+	return get(ctx, pageURL)
+}
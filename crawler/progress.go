@@ -0,0 +1,51 @@
+package crawler
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/schollz/progressbar/v3"
+)
+
+// crawlProgress is a progress bar whose description keeps a running count of the
+// pages crawled and failed. It is safe for use by several workers at once.
+type crawlProgress struct {
+	mu          sync.Mutex
+	bar         *progressbar.ProgressBar
+	description string
+	crawled     int
+	failed      int
+}
+
+// newCrawlProgress creates a progress bar for total pages.
+func newCrawlProgress(total int, description string) *crawlProgress {
+	return &crawlProgress{
+		bar:         progressbar.NewOptions(total, progressbar.OptionSetDescription(description)),
+		description: description,
+	}
+}
+
+// pageResult is how a page finished, for the progress counters.
+type pageResult int
+
+const (
+	resultCancelled pageResult = iota // The crawl was stopped before the page finished
+	resultCrawled
+	resultFailed
+)
+
+// done advances the bar by one page and counts its result. The counters and the
+// bar are updated together, so the counts shown never go backwards.
+func (p *crawlProgress) done(result pageResult) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	switch result {
+	case resultCrawled:
+		p.crawled++
+	case resultFailed:
+		p.failed++
+	}
+	p.bar.Describe(fmt.Sprintf("%s (crawled %d, failed %d)", p.description, p.crawled, p.failed))
+	p.bar.Add(1)
+}
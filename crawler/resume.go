@@ -0,0 +1,118 @@
+package crawler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+	"time"
+)
+
+// ResumeState records which URLs have been crawled so an interrupted crawl can
+// pick up where it left off.
+type ResumeState struct {
+	path      string
+	mu        sync.Mutex
+	completed map[string]bool
+
+	// Saving while the crawl runs, when enabled by SaveEvery
+	interval time.Duration
+	timer    *time.Timer // Save scheduled by markDone, if one is pending
+	saveMu   sync.Mutex  // Serializes saves, which share the temporary file
+}
+
+// resumeFile is the on-disk format of a resume state file.
+type resumeFile struct {
+	Completed []string `json:"Completed"`
+}
+
+// LoadResumeState reads the resume state at path. A missing file yields an empty state.
+func LoadResumeState(path string) (*ResumeState, error) {
+	state := &ResumeState{path: path, completed: make(map[string]bool)}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return state, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading resume state %s: %w", path, err)
+	}
+
+	var file resumeFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("error parsing resume state %s: %w", path, err)
+	}
+	for _, u := range file.Completed {
+		state.completed[u] = true
+	}
+	return state, nil
+}
+
+// Done reports whether the URL was crawled in a previous run.
+func (s *ResumeState) Done(pageURL string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.completed[pageURL]
+}
+
+// SaveEvery makes the state save itself while the crawl runs, at most once per
+// interval after URLs are marked crawled, so a crawl that dies before its final
+// Save still leaves its progress behind. URLs are only marked once their pages
+// have been handed to OnPage or Stream, so only enable it when pages are written
+// out as they are delivered; otherwise the saved state can run ahead of the output.
+func (s *ResumeState) SaveEvery(interval time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.interval = interval
+}
+
+// markDone records the URL as crawled, scheduling a save if SaveEvery is in use
+// and none is pending.
+func (s *ResumeState) markDone(pageURL string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.completed[pageURL] = true
+	if s.interval > 0 && s.timer == nil {
+		s.timer = time.AfterFunc(s.interval, func() {
+			if err := s.Save(); err != nil {
+				fmt.Printf("Error saving resume state: %v\n", err)
+			}
+		})
+	}
+}
+
+// Save writes the state to its file, replacing it atomically, and cancels any
+// save scheduled by markDone. Call it once the crawled pages have been written
+// so the state never runs ahead of the output.
+func (s *ResumeState) Save() error {
+	s.saveMu.Lock()
+	defer s.saveMu.Unlock()
+
+	s.mu.Lock()
+	if s.timer != nil {
+		s.timer.Stop()
+		s.timer = nil
+	}
+	file := resumeFile{Completed: make([]string, 0, len(s.completed))}
+	for u := range s.completed {
+		file.Completed = append(file.Completed, u)
+	}
+	s.mu.Unlock()
+	sort.Strings(file.Completed)
+
+	data, err := json.MarshalIndent(file, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal resume state: %w", err)
+	}
+
+	tmpPath := s.path + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0o644); err != nil {
+		return fmt.Errorf("error writing resume state %s: %w", tmpPath, err)
+	}
+	if err := os.Rename(tmpPath, s.path); err != nil {
+		return fmt.Errorf("error saving resume state %s: %w", s.path, err)
+	}
+	return nil
+}
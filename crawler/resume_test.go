@@ -0,0 +1,151 @@
+package crawler
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestResumeStateRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+	state, err := LoadResumeState(path)
+	if err != nil {
+		t.Fatalf("LoadResumeState of a missing file: %v", err)
+	}
+	state.markDone("https://example.com/b")
+	state.markDone("https://example.com/a")
+	if err := state.Save(); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	if _, err := os.Stat(path + ".tmp"); !os.IsNotExist(err) {
+		t.Errorf("temporary file left behind: %v", err)
+	}
+
+	loaded, err := LoadResumeState(path)
+	if err != nil {
+		t.Fatalf("LoadResumeState: %v", err)
+	}
+	if !loaded.Done("https://example.com/a") || !loaded.Done("https://example.com/b") || loaded.Done("https://example.com/c") {
+		t.Errorf("loaded state = %v, want a and b done", loaded.completed)
+	}
+}
+
+func TestResumeStateSavesAsPagesComplete(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+	state, err := LoadResumeState(path)
+	if err != nil {
+		t.Fatalf("LoadResumeState: %v", err)
+	}
+	state.SaveEvery(10 * time.Millisecond)
+	state.markDone("https://example.com/a")
+	state.markDone("https://example.com/b") // Covered by the save already scheduled
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		loaded, err := LoadResumeState(path)
+		if err == nil && loaded.Done("https://example.com/a") && loaded.Done("https://example.com/b") {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("state not saved after markDone (last load error: %v)", err)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestResumeStateWithoutSaveEvery(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+	state, err := LoadResumeState(path)
+	if err != nil {
+		t.Fatalf("LoadResumeState: %v", err)
+	}
+	state.markDone("https://example.com/a")
+	time.Sleep(20 * time.Millisecond)
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("state saved before Save was called: %v", err)
+	}
+}
+
+func TestResumeStateSavesOnlyDeliveredPages(t *testing.T) {
+	release := make(chan struct{})
+	var releaseOnce sync.Once
+	releaseSlow := func() { releaseOnce.Do(func() { close(release) }) }
+	defer releaseSlow()
+
+	served := make(chan string, 2)
+	var serverURL string
+	server := newTestHandlerServer(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/sitemap.xml":
+			fmt.Fprintf(w, `<urlset><url><loc>%[1]s/slow</loc></url><url><loc>%[1]s/b</loc></url><url><loc>%[1]s/c</loc></url></urlset>`, serverURL)
+		case "/slow":
+			<-release
+			fmt.Fprint(w, "<html><body><p>Slow</p></body></html>")
+		default:
+			fmt.Fprint(w, "<html><body><p>Fast</p></body></html>")
+			served <- r.URL.Path
+		}
+	})
+	serverURL = server.URL
+
+	path := filepath.Join(t.TempDir(), "state.json")
+	state, err := LoadResumeState(path)
+	if err != nil {
+		t.Fatalf("LoadResumeState: %v", err)
+	}
+	state.SaveEvery(time.Millisecond)
+
+	var mu sync.Mutex
+	var delivered []string
+	opts := Options{CSSSelector: "body", Format: "txt", Concurrency: 3, OrderedStream: true, Resume: state,
+		OnPage: func(page Page) {
+			mu.Lock()
+			defer mu.Unlock()
+			delivered = append(delivered, page.URL)
+		}}
+	done := make(chan error, 1)
+	go func() {
+		_, err := CrawlSitemap(context.Background(), serverURL+"/sitemap.xml", opts)
+		done <- err
+	}()
+
+	// b and c are fetched but held behind the slow first page, so they mustn't be saved
+	<-served
+	<-served
+	time.Sleep(50 * time.Millisecond)
+	saved, err := LoadResumeState(path)
+	if err != nil {
+		t.Fatalf("LoadResumeState: %v", err)
+	}
+	if saved.Done(serverURL+"/b") || saved.Done(serverURL+"/c") {
+		t.Errorf("saved state = %v, lists pages not yet delivered", saved.completed)
+	}
+
+	releaseSlow()
+	if err := <-done; err != nil {
+		t.Fatalf("CrawlSitemap: %v", err)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if len(delivered) != 3 {
+		t.Fatalf("delivered %v, want all three pages", delivered)
+	}
+
+	// Every page is saved once delivered, which also leaves no save pending past the test
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		saved, err := LoadResumeState(path)
+		if err == nil && saved.Done(delivered[0]) && saved.Done(delivered[1]) && saved.Done(delivered[2]) {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("delivered pages %v not saved (last load error: %v)", delivered, err)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
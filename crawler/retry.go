@@ -0,0 +1,190 @@
+package crawler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// emptyRetryDelay is how long to wait before refetching a page that came back empty.
+const emptyRetryDelay = 2 * time.Second
+
+// errSelectorNotFound is returned when the content selector matches nothing on a page.
+var errSelectorNotFound = errors.New("CSS selector not found")
+
+// extractPageRetrying extracts a page, refetching it up to opts.RetryOnEmpty times
+// after a short delay while it comes back empty, as some script-heavy pages serve
+// a bare shell on the first request. Responses with one of opts.RetryStatus codes
+// are refetched up to opts.StatusRetries times, waiting as long as the server asks
+// with Retry-After, or backing off exponentially when it doesn't say.
+//
+// Time spent retrying is charged to budget, and once it runs out pages are no
+// longer retried.
+func extractPageRetrying(ctx context.Context, pageURL string, opts Options, throttle *throttle, budget *retryBudget) (Page, error) {
+	page, err := extractPage(ctx, pageURL, opts)
+	emptyRetries, statusRetries := 0, 0
+	for {
+		var delay time.Duration
+		var status *statusError
+		switch {
+		case errors.As(err, &status) && statusRetries < opts.StatusRetries:
+			statusRetries++
+			delay = status.retryAfter
+			if delay == 0 {
+				delay = statusRetryDelay << (statusRetries - 1)
+			}
+			if !budget.available() {
+				return page, err
+			}
+			fmt.Printf("Refetching %s in %s, which returned HTTP %d (retry %d of %d)\n", pageURL, delay.Round(time.Second), status.code, statusRetries, opts.StatusRetries)
+		case emptyRetries < opts.RetryOnEmpty && isEmptyResult(page, err, opts):
+			emptyRetries++
+			delay = emptyRetryDelay
+			if !budget.available() {
+				return page, err
+			}
+			fmt.Printf("Refetching %s, which came back empty (retry %d of %d)\n", pageURL, emptyRetries, opts.RetryOnEmpty)
+		default:
+			return page, err
+		}
+		start := time.Now()
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return page, err
+		}
+		if waitErr := throttle.wait(ctx, pageURL); waitErr != nil {
+			return page, err
+		}
+		page, err = extractPage(withRequestTimeout(ctx, retryTimeout(emptyRetries+statusRetries, opts)), pageURL, opts)
+		budget.spend(time.Since(start))
+	}
+}
+
+// statusRetryDelay is the first wait before refetching a page that answered with a
+// retryable status and no Retry-After; it doubles with each retry.
+const statusRetryDelay = time.Second
+
+// maxRetryAfter caps how long a Retry-After header can make a retry wait.
+const maxRetryAfter = 2 * time.Minute
+
+// statusError is returned for a response whose status is one of RetryStatus.
+type statusError struct {
+	code       int
+	retryAfter time.Duration // The wait asked for by a 429 or 503 response, if any
+}
+
+// Error names the status code.
+func (e *statusError) Error() string {
+	return fmt.Sprintf("HTTP status %d", e.code)
+}
+
+// retryableStatus returns a statusError for a response with one of the RetryStatus
+// codes, or nil for any other response.
+func retryableStatus(res *http.Response, opts Options) error {
+	if !slices.Contains(opts.RetryStatus, res.StatusCode) {
+		return nil
+	}
+	err := &statusError{code: res.StatusCode}
+	if res.StatusCode == http.StatusTooManyRequests || res.StatusCode == http.StatusServiceUnavailable {
+		err.retryAfter = parseRetryAfter(res.Header.Get("Retry-After"), time.Now())
+	}
+	return err
+}
+
+// parseRetryAfter reads a Retry-After header, given either as a number of seconds
+// or as an HTTP date, into a wait of at most maxRetryAfter. It returns 0 when the
+// header is missing or invalid.
+func parseRetryAfter(value string, now time.Time) time.Duration {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0
+	}
+	var wait time.Duration
+	if seconds, err := strconv.Atoi(value); err == nil {
+		wait = time.Duration(seconds) * time.Second
+	} else if date, err := http.ParseTime(value); err == nil {
+		wait = date.Sub(now)
+	}
+	return min(max(wait, 0), maxRetryAfter)
+}
+
+// retryBudget caps the total time a crawl spends retrying pages, across all of
+// them, so many slow retries can't stretch a crawl out indefinitely. It is safe
+// for concurrent use; a nil budget is unlimited.
+type retryBudget struct {
+	mu        sync.Mutex
+	total     time.Duration
+	remaining time.Duration
+	warned    bool
+}
+
+// newRetryBudget creates a budget of total, or returns nil (unlimited) if total isn't positive.
+func newRetryBudget(total time.Duration) *retryBudget {
+	if total <= 0 {
+		return nil
+	}
+	return &retryBudget{total: total, remaining: total}
+}
+
+// available reports whether there is budget left for another retry, warning
+// the first time it has run out.
+func (b *retryBudget) available() bool {
+	if b == nil {
+		return true
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.remaining > 0 {
+		return true
+	}
+	if !b.warned {
+		b.warned = true
+		fmt.Printf("Retry budget of %s used up, no longer retrying pages\n", b.total)
+	}
+	return false
+}
+
+// spend charges time spent retrying to the budget.
+func (b *retryBudget) spend(elapsed time.Duration) {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.remaining -= elapsed
+}
+
+// maxRetryTimeout caps the per-request timeout of retries grown by RetryTimeoutMultiplier.
+const maxRetryTimeout = 5 * time.Minute
+
+// retryTimeout returns the per-request timeout for a retry: the client's timeout
+// grown linearly by RetryTimeoutMultiplier, so with a multiplier of 2 the first
+// retry gets twice the timeout and the second three times. It returns 0 (the
+// client's own timeout) when the multiplier doesn't apply.
+func retryTimeout(retry int, opts Options) time.Duration {
+	base := Client.Timeout
+	if opts.RetryTimeoutMultiplier <= 1 || base <= 0 {
+		return 0
+	}
+	timeout := time.Duration(float64(base) * (1 + (opts.RetryTimeoutMultiplier-1)*float64(retry)))
+	return min(timeout, max(maxRetryTimeout, base))
+}
+
+// isEmptyResult reports whether an extraction found no content: the selector
+// matched nothing, or the content has fewer words than MinWords (or none at all).
+func isEmptyResult(page Page, err error, opts Options) bool {
+	if err != nil {
+		return errors.Is(err, errSelectorNotFound)
+	}
+	return len(strings.Fields(page.Content)) < max(opts.MinWords, 1)
+}
@@ -0,0 +1,59 @@
+package crawler
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRetryStatusHonorsRetryAfter(t *testing.T) {
+	var requests atomic.Int32
+	server := newTestHandlerServer(t, func(w http.ResponseWriter, r *http.Request) {
+		if requests.Add(1) == 1 {
+			w.Header().Set("Retry-After", "1")
+			http.Error(w, "slow down", http.StatusTooManyRequests)
+			return
+		}
+		fmt.Fprint(w, "<html><body><p>Welcome back</p></body></html>")
+	})
+
+	opts := Options{CSSSelector: "body", Format: "txt", RetryStatus: []int{429, 503}, StatusRetries: 3}
+	start := time.Now()
+	page, err := extractPageRetrying(context.Background(), server.URL+"/page", opts, newThrottle(opts), nil)
+	if err != nil {
+		t.Fatalf("extractPageRetrying: %v", err)
+	}
+	if strings.TrimSpace(page.Content) != "Welcome back" {
+		t.Errorf("Content = %q, want the refetched page", page.Content)
+	}
+	if n := requests.Load(); n != 2 {
+		t.Errorf("made %d requests, want the 429 refetched once", n)
+	}
+	if elapsed := time.Since(start); elapsed < time.Second {
+		t.Errorf("refetched after %s, before the one second asked for by Retry-After", elapsed)
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2024, 1, 2, 10, 0, 0, 0, time.UTC)
+	tests := []struct {
+		value string
+		want  time.Duration
+	}{
+		{"", 0},
+		{"5", 5 * time.Second},
+		{"-5", 0},
+		{"soon", 0},
+		{"Tue, 02 Jan 2024 10:00:30 GMT", 30 * time.Second},
+		{"86400", maxRetryAfter},
+	}
+	for _, tt := range tests {
+		if got := parseRetryAfter(tt.value, now); got != tt.want {
+			t.Errorf("parseRetryAfter(%q) = %s, want %s", tt.value, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,128 @@
+package crawler
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// robotsAgent is the user agent name matched against robots.txt groups.
+const robotsAgent = "sitemapexport"
+
+// maxRobotsBytes is how much of a robots.txt file is read, as crawlers commonly
+// ignore anything past 500 KiB.
+const maxRobotsBytes = 500 * 1024
+
+// fetchCrawlDelay fetches robots.txt for the host of pageURL and returns the
+// Crawl-delay that applies to this crawler, or zero if there is none.
+func fetchCrawlDelay(ctx context.Context, pageURL string) time.Duration {
+	robotsURL, err := robotsURLFor(pageURL)
+	if err != nil {
+		return 0
+	}
+
+	res, err := get(ctx, robotsURL)
+	if err != nil {
+		return 0
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return 0
+	}
+	return parseCrawlDelay(io.LimitReader(res.Body, maxRobotsBytes))
+}
+
+// robotsURLFor returns the robots.txt URL for the host of pageURL.
+func robotsURLFor(pageURL string) (string, error) {
+	parsedURL, err := url.Parse(pageURL)
+	if err != nil {
+		return "", err
+	}
+	return parsedURL.Scheme + "://" + parsedURL.Host + "/robots.txt", nil
+}
+
+// parseCrawlDelay reads robots.txt and returns the Crawl-delay of the group for
+// this crawler, falling back to the "*" group.
+func parseCrawlDelay(r io.Reader) time.Duration {
+	var (
+		agents       []string
+		inRules      bool
+		ownDelay     time.Duration
+		defaultDelay time.Duration
+	)
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line, _, _ := strings.Cut(scanner.Text(), "#")
+		key, value, found := strings.Cut(line, ":")
+		if !found {
+			continue
+		}
+		key = strings.ToLower(strings.TrimSpace(key))
+		value = strings.TrimSpace(value)
+
+		switch key {
+		case "user-agent":
+			// A user-agent line after rules starts a new group
+			if inRules {
+				agents, inRules = nil, false
+			}
+			agents = append(agents, value)
+		case "crawl-delay":
+			inRules = true
+			seconds, err := strconv.ParseFloat(value, 64)
+			if err != nil || seconds < 0 {
+				continue
+			}
+			delay := time.Duration(seconds * float64(time.Second))
+			for _, agent := range agents {
+				switch {
+				case matchesRobotsAgent(agent):
+					ownDelay = delay
+				case agent == "*":
+					defaultDelay = delay
+				}
+			}
+		case "sitemap":
+			// Sitemap lines stand apart from the user-agent groups
+		default:
+			inRules = true
+		}
+	}
+
+	if ownDelay > 0 {
+		return ownDelay
+	}
+	return defaultDelay
+}
+
+// matchesRobotsAgent reports whether a robots.txt User-agent value names this
+// crawler: its product token, the part before any "/version", must be ours,
+// compared case-insensitively.
+func matchesRobotsAgent(value string) bool {
+	token, _, _ := strings.Cut(value, "/")
+	return strings.EqualFold(strings.TrimSpace(token), robotsAgent)
+}
+
+// parseSitemapDirectives returns the URLs of every Sitemap: line in robots.txt, in order.
+func parseSitemapDirectives(r io.Reader) []string {
+	var sitemaps []string
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line, _, _ := strings.Cut(scanner.Text(), "#")
+		key, value, found := strings.Cut(line, ":")
+		if !found || !strings.EqualFold(strings.TrimSpace(key), "sitemap") {
+			continue
+		}
+		if value = strings.TrimSpace(value); value != "" {
+			sitemaps = append(sitemaps, value)
+		}
+	}
+	return sitemaps
+}
@@ -0,0 +1,76 @@
+package crawler
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestParseCrawlDelay(t *testing.T) {
+	tests := []struct {
+		name, robots string
+		want         time.Duration
+	}{
+		{"own group", "User-agent: sitemapExport\nCrawl-delay: 2\n\nUser-agent: *\nCrawl-delay: 5\n", 2 * time.Second},
+		{"own group with version", "User-agent: SitemapExport/1.0\nCrawl-delay: 2\n", 2 * time.Second},
+		{"default group", "User-agent: otherbot\nCrawl-delay: 2\n\nUser-agent: *\nCrawl-delay: 0.5\n", 500 * time.Millisecond},
+		{"shared group", "User-agent: otherbot\nUser-agent: sitemapexport\nCrawl-delay: 3\n", 3 * time.Second},
+		// Names that merely appear inside ours, or contain it, aren't ours
+		{"substring of our name", "User-agent: sitemap\nCrawl-delay: 2\n", 0},
+		{"single letter", "User-agent: s\nCrawl-delay: 2\n", 0},
+		{"longer name", "User-agent: sitemapexporter\nCrawl-delay: 2\n", 0},
+		{"empty agent", "User-agent:\nCrawl-delay: 2\n", 0},
+		{"empty agent in default group", "User-agent:\nUser-agent: *\nCrawl-delay: 1\n", time.Second},
+		{"invalid delay", "User-agent: *\nCrawl-delay: soon\n", 0},
+		{"comments", "User-agent: * # everyone\nCrawl-delay: 4 # seconds\n", 4 * time.Second},
+	}
+	for _, tt := range tests {
+		if got := parseCrawlDelay(strings.NewReader(tt.robots)); got != tt.want {
+			t.Errorf("%s: parseCrawlDelay = %s, want %s", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestThrottleLooksUpEachHostOnce(t *testing.T) {
+	var fetches atomic.Int32
+	release := make(chan struct{})
+	server := newTestHandlerServer(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/robots.txt" {
+			fetches.Add(1)
+			<-release
+			w.Write([]byte("User-agent: *\nCrawl-delay: 0.01\n"))
+		}
+	})
+
+	throttle := newThrottle(Options{RobotsDelay: true})
+	var wg sync.WaitGroup
+	for i := 0; i < 3; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			throttle.wait(context.Background(), server.URL+"/page.html")
+		}()
+	}
+
+	// Another host isn't held up while robots.txt is being fetched
+	done := make(chan struct{})
+	go func() {
+		throttle.wait(context.Background(), "http://127.0.0.1:1/page.html")
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Error("wait for another host blocked behind the robots.txt fetch")
+	}
+
+	close(release)
+	wg.Wait()
+	if n := fetches.Load(); n != 1 {
+		t.Errorf("robots.txt fetched %d times, want once", n)
+	}
+}
@@ -0,0 +1,117 @@
+package crawler
+
+import (
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// selectSection narrows each match down to the section under the first heading
+// titled title (compared case-insensitively, ignoring permalink markers such as
+// "#" or "¶"): the heading itself and everything after it up to the next heading
+// of the same or a higher level. The section may run out of the heading's own
+// container, as in <section><h2>...</h2></section><p>...</p>, but not out of the
+// match. Matches without the heading are dropped from the returned selection.
+func selectSection(selection *goquery.Selection, title string) *goquery.Selection {
+	title = normalizeHeading(title)
+	return selection.FilterFunction(func(i int, s *goquery.Selection) bool {
+		var heading *html.Node
+		s.Find("h1, h2, h3, h4, h5, h6").EachWithBreak(func(i int, h *goquery.Selection) bool {
+			if strings.EqualFold(normalizeHeading(h.Text()), title) {
+				heading = h.Get(0)
+			}
+			return heading == nil
+		})
+		if heading == nil {
+			return false
+		}
+
+		root := s.Get(0)
+		nodes := sectionNodes(root, heading)
+		for _, n := range nodes {
+			n.Parent.RemoveChild(n)
+		}
+		for child := root.FirstChild; child != nil; child = root.FirstChild {
+			root.RemoveChild(child)
+		}
+		for _, n := range nodes {
+			root.AppendChild(n)
+		}
+		return true
+	})
+}
+
+// sectionNodes returns the heading and the nodes following it in document order,
+// within root, up to the next heading of the same or a higher level. Containers
+// holding that next heading are entered rather than kept whole.
+func sectionNodes(root, heading *html.Node) []*html.Node {
+	level := headingLevel(heading)
+	nodes := []*html.Node{heading}
+	for n := heading; n != root; n = n.Parent {
+		for sibling := n.NextSibling; sibling != nil; sibling = sibling.NextSibling {
+			if collectUntilHeading(sibling, level, &nodes) {
+				return nodes
+			}
+		}
+	}
+	return nodes
+}
+
+// collectUntilHeading adds n to nodes, or, when n contains a heading of level or
+// higher, the parts of it before that heading. It reports whether the heading
+// was reached.
+func collectUntilHeading(n *html.Node, level int, nodes *[]*html.Node) bool {
+	if l := headingLevel(n); l > 0 && l <= level {
+		return true
+	}
+	if !containsHeading(n, level) {
+		*nodes = append(*nodes, n)
+		return false
+	}
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		if collectUntilHeading(child, level, nodes) {
+			return true
+		}
+	}
+	return false
+}
+
+// containsHeading reports whether any descendant of n is a heading of level or higher.
+func containsHeading(n *html.Node, level int) bool {
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		if l := headingLevel(child); (l > 0 && l <= level) || containsHeading(child, level) {
+			return true
+		}
+	}
+	return false
+}
+
+// headingLevel returns 1 to 6 for an <h1> to <h6> element, and 0 for any other node.
+func headingLevel(n *html.Node) int {
+	if n.Type != html.ElementNode {
+		return 0
+	}
+	switch n.DataAtom {
+	case atom.H1:
+		return 1
+	case atom.H2:
+		return 2
+	case atom.H3:
+		return 3
+	case atom.H4:
+		return 4
+	case atom.H5:
+		return 5
+	case atom.H6:
+		return 6
+	}
+	return 0
+}
+
+// normalizeHeading collapses the whitespace in a heading's text and trims the
+// permalink markers that documentation sites add to headings.
+func normalizeHeading(text string) string {
+	return strings.Trim(strings.Join(strings.Fields(text), " "), " #¶§")
+}
@@ -0,0 +1,75 @@
+package crawler
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"path"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// SelectorMap assigns a content CSS selector to pages by host. Keys are either an
+// exact host ("docs.example.com") or a glob pattern ("*.example.com").
+type SelectorMap map[string]string
+
+// LoadSelectorMap reads a YAML file mapping hosts or host patterns to CSS selectors, e.g.
+//
+//	docs.example.com: "main .docs-content"
+//	"*.blog.example.com": "article"
+func LoadSelectorMap(filename string) (SelectorMap, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("error reading selector map %s: %w", filename, err)
+	}
+
+	var selectors SelectorMap
+	if err := yaml.Unmarshal(data, &selectors); err != nil {
+		return nil, fmt.Errorf("error parsing selector map %s: %w", filename, err)
+	}
+	for pattern, selector := range selectors {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("invalid host pattern %q in %s: %w", pattern, filename, err)
+		}
+		if strings.TrimSpace(selector) == "" {
+			return nil, fmt.Errorf("empty selector for %q in %s", pattern, filename)
+		}
+	}
+	return selectors, nil
+}
+
+// selectorFor returns the selector mapped to the host of pageURL. An exact host wins
+// over patterns, and longer (more specific) patterns win over shorter ones. It
+// returns "" when nothing matches.
+func (m SelectorMap) selectorFor(pageURL string) string {
+	if len(m) == 0 {
+		return ""
+	}
+	parsedURL, err := url.Parse(pageURL)
+	if err != nil {
+		return ""
+	}
+	host := strings.ToLower(parsedURL.Hostname())
+	if selector, ok := m[host]; ok {
+		return selector
+	}
+
+	patterns := make([]string, 0, len(m))
+	for pattern := range m {
+		patterns = append(patterns, pattern)
+	}
+	sort.Slice(patterns, func(i, j int) bool {
+		if len(patterns[i]) != len(patterns[j]) {
+			return len(patterns[i]) > len(patterns[j])
+		}
+		return patterns[i] < patterns[j]
+	})
+	for _, pattern := range patterns {
+		if matched, _ := path.Match(strings.ToLower(pattern), host); matched {
+			return m[pattern]
+		}
+	}
+	return ""
+}
@@ -0,0 +1,122 @@
+package crawler
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// commonSelectors are the content containers most sites use, tried on every page.
+var commonSelectors = []string{
+	"article", "main", "[role=main]",
+	"#content", "#main", "#main-content", "#primary", "#post",
+	".content", ".main-content", ".post", ".post-content", ".entry-content", ".article-body", ".article-content",
+	"body",
+}
+
+// containerHints are the words in an id or class that mark an element as a likely
+// content container, for finding the site-specific ones.
+var containerHints = []string{"content", "main", "post", "article", "entry", "body", "text"}
+
+// SelectorCandidate is a CSS selector that matches content on a page.
+type SelectorCandidate struct {
+	Selector   string // CSS selector, as it would be passed to --css
+	Matches    int    // Number of elements it matches
+	TextLength int    // Characters of text in the matched elements, with whitespace collapsed
+}
+
+// ListSelectors fetches a page and ranks the content containers found on it, the
+// common ones (article, main, #content, .post, ...) and those whose id or class
+// suggests content, by the length of the text each would extract, longest first.
+// Selectors that match nothing or only empty elements are left out.
+func ListSelectors(ctx context.Context, pageURL string, opts Options) ([]SelectorCandidate, error) {
+	res, err := fetchPage(ctx, pageURL, opts)
+	if err != nil {
+		return nil, fmt.Errorf("error visiting URL %s: %w", pageURL, err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected HTTP status visiting URL %s: %d", pageURL, res.StatusCode)
+	}
+
+	doc, err := goquery.NewDocumentFromReader(res.Body)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing HTML from %s: %w", pageURL, err)
+	}
+	doc.Find("script, style, noscript, template").Remove()
+
+	var candidates []SelectorCandidate
+	for _, selector := range candidateSelectors(doc) {
+		matches := doc.Find(selector)
+		length := 0
+		matches.Each(func(i int, s *goquery.Selection) {
+			length += len([]rune(strings.Join(strings.Fields(s.Text()), " ")))
+		})
+		if length > 0 {
+			candidates = append(candidates, SelectorCandidate{Selector: selector, Matches: matches.Length(), TextLength: length})
+		}
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].TextLength > candidates[j].TextLength
+	})
+	return candidates, nil
+}
+
+// candidateSelectors returns the common selectors followed by an #id or .class
+// selector for every element whose id or class contains a container hint, each
+// selector once.
+func candidateSelectors(doc *goquery.Document) []string {
+	selectors := append([]string(nil), commonSelectors...)
+	seen := make(map[string]bool)
+	for _, selector := range selectors {
+		seen[selector] = true
+	}
+	add := func(selector string) {
+		if !seen[selector] {
+			seen[selector] = true
+			selectors = append(selectors, selector)
+		}
+	}
+
+	doc.Find("[id], [class]").Each(func(i int, s *goquery.Selection) {
+		if id := s.AttrOr("id", ""); hasContainerHint(id) && isPlainName(id) {
+			add("#" + id)
+		}
+		for _, class := range strings.Fields(s.AttrOr("class", "")) {
+			if hasContainerHint(class) && isPlainName(class) {
+				add("." + class)
+			}
+		}
+	})
+	return selectors
+}
+
+// hasContainerHint reports whether an id or class name contains a container hint.
+func hasContainerHint(name string) bool {
+	name = strings.ToLower(name)
+	for _, hint := range containerHints {
+		if strings.Contains(name, hint) {
+			return true
+		}
+	}
+	return false
+}
+
+// isPlainName reports whether an id or class can be used in a selector without
+// escaping: letters, digits, hyphens, and underscores, not starting with a digit.
+func isPlainName(name string) bool {
+	for i, r := range name {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r == '-', r == '_':
+		case r >= '0' && r <= '9' && i > 0:
+		default:
+			return false
+		}
+	}
+	return name != ""
+}
@@ -0,0 +1,112 @@
+package crawler
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// SiteMeta describes a site (host) encountered during a crawl.
+type SiteMeta struct {
+	Host     string `json:"Host"`
+	SiteName string `json:"SiteName,omitempty"` // From og:site_name
+	Favicon  string `json:"Favicon,omitempty"`  // URL of the site's icon
+}
+
+// SiteCollector gathers SiteMeta for each distinct host of the crawled pages. Each
+// host is looked at once, from the first of its pages to be extracted.
+type SiteCollector struct {
+	mu    sync.Mutex
+	sites map[string]*SiteMeta
+}
+
+// NewSiteCollector creates a collector with no sites.
+func NewSiteCollector() *SiteCollector {
+	return &SiteCollector{sites: make(map[string]*SiteMeta)}
+}
+
+// Sites returns the metadata collected so far, ordered by host.
+func (c *SiteCollector) Sites() []SiteMeta {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	sites := make([]SiteMeta, 0, len(c.sites))
+	for _, site := range c.sites {
+		sites = append(sites, *site)
+	}
+	sort.Slice(sites, func(i, j int) bool { return sites[i].Host < sites[j].Host })
+	return sites
+}
+
+// observe records the metadata of the host of pageURL from its document, unless
+// that host has already been seen. Only /favicon.ico may need a request of its own.
+func (c *SiteCollector) observe(ctx context.Context, doc *goquery.Document, pageURL string, opts Options) {
+	parsedURL, err := url.Parse(pageURL)
+	if err != nil || parsedURL.Host == "" {
+		return
+	}
+
+	c.mu.Lock()
+	if _, seen := c.sites[parsedURL.Host]; seen {
+		c.mu.Unlock()
+		return
+	}
+	site := &SiteMeta{Host: parsedURL.Host}
+	c.sites[parsedURL.Host] = site // Claim the host before fetching outside the lock
+	c.mu.Unlock()
+
+	siteName, _ := doc.Find(`meta[property="og:site_name"]`).Attr("content")
+	favicon := iconURL(doc, pageURL)
+	if favicon == "" {
+		favicon = defaultFavicon(ctx, parsedURL, opts)
+	}
+
+	c.mu.Lock()
+	site.SiteName = strings.TrimSpace(siteName)
+	site.Favicon = favicon
+	c.mu.Unlock()
+}
+
+// iconURL returns the absolute URL of the document's <link rel="icon"> (or
+// "shortcut icon"), or "" when it declares none.
+func iconURL(doc *goquery.Document, pageURL string) string {
+	var href string
+	doc.Find("link[rel][href]").EachWithBreak(func(i int, s *goquery.Selection) bool {
+		for _, rel := range strings.Fields(strings.ToLower(s.AttrOr("rel", ""))) {
+			if rel == "icon" {
+				href = strings.TrimSpace(s.AttrOr("href", ""))
+				return false
+			}
+		}
+		return true
+	})
+	if href == "" {
+		return ""
+	}
+	return toAbsoluteURL(pageURL, href)
+}
+
+// defaultFavicon returns the host's /favicon.ico URL if it exists, or "".
+func defaultFavicon(ctx context.Context, siteURL *url.URL, opts Options) string {
+	faviconURL := siteURL.Scheme + "://" + siteURL.Host + "/favicon.ico"
+
+	ctx, cancel := metaContext(ctx, opts)
+	defer cancel()
+	res, err := get(ctx, faviconURL)
+	if err != nil {
+		return ""
+	}
+	defer res.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(res.Body, 1<<20)) // Drain so the connection is reused
+
+	if res.StatusCode != http.StatusOK {
+		return ""
+	}
+	return faviconURL
+}
@@ -0,0 +1,38 @@
+package crawler
+
+import (
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// Social sharing tags read when a page lacks the standard ones, OpenGraph first.
+var (
+	socialTitleTags       = []string{"og:title", "twitter:title"}
+	socialDescriptionTags = []string{"og:description", "twitter:description"}
+	socialImageTags       = []string{"og:image", "og:image:url", "twitter:image", "twitter:image:src"}
+)
+
+// socialMeta returns the content of the first of the given meta tags that the
+// document declares with a non-empty value. Each tag is matched by its property
+// attribute, as OpenGraph specifies, or its name attribute, as Twitter Cards do,
+// since sites mix the two up.
+func socialMeta(doc *goquery.Document, tags []string) string {
+	for _, tag := range tags {
+		var content string
+		doc.Find("meta[property][content], meta[name][content]").EachWithBreak(func(i int, s *goquery.Selection) bool {
+			key := s.AttrOr("property", "")
+			if key == "" {
+				key = s.AttrOr("name", "")
+			}
+			if strings.EqualFold(strings.TrimSpace(key), tag) {
+				content = strings.TrimSpace(s.AttrOr("content", ""))
+			}
+			return content == ""
+		})
+		if content != "" {
+			return content
+		}
+	}
+	return ""
+}
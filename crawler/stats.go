@@ -0,0 +1,92 @@
+package crawler
+
+import (
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// CrawlStats tallies the outcome of a crawl for a machine-readable summary. It is
+// safe for use by several workers at once.
+type CrawlStats struct {
+	mu        sync.Mutex
+	total     int
+	succeeded int
+	bytes     int64
+	statuses  map[int]int
+	failures  []FailedURL
+}
+
+// FailedURL is a URL that failed to extract, with the reason.
+type FailedURL struct {
+	URL   string `json:"URL"`
+	Error string `json:"Error"`
+}
+
+// CrawlSummary is the outcome of a crawl. Total counts every URL queued, so it
+// also includes pages left unfinished when the crawl was stopped early.
+type CrawlSummary struct {
+	Total          int         `json:"Total"`
+	Succeeded      int         `json:"Succeeded"`
+	Failed         int         `json:"Failed"`
+	ElapsedSeconds float64     `json:"ElapsedSeconds"`
+	BytesFetched   int64       `json:"BytesFetched"`
+	StatusCodes    map[int]int `json:"StatusCodes"` // Responses received for pages, by HTTP status code
+	FailedURLs     []FailedURL `json:"FailedURLs"`
+}
+
+// NewCrawlStats creates an empty set of crawl statistics.
+func NewCrawlStats() *CrawlStats {
+	return &CrawlStats{statuses: make(map[int]int)}
+}
+
+// queued counts n more URLs to be crawled.
+func (s *CrawlStats) queued(n int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.total += n
+}
+
+// response records a page response and the bytes read from it.
+func (s *CrawlStats) response(status int, bytes int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.statuses[status]++
+	s.bytes += bytes
+}
+
+// done records whether a page was extracted, keeping the error of one that failed.
+func (s *CrawlStats) done(pageURL string, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err != nil {
+		s.failures = append(s.failures, FailedURL{URL: pageURL, Error: err.Error()})
+		return
+	}
+	s.succeeded++
+}
+
+// Summary returns the statistics gathered so far, with the crawl's elapsed time.
+// Failed URLs are listed in URL order, since workers finish in no fixed order.
+func (s *CrawlStats) Summary(elapsed time.Duration) CrawlSummary {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	failures := slices.Clone(s.failures)
+	slices.SortFunc(failures, func(a, b FailedURL) int { return strings.Compare(a.URL, b.URL) })
+	if failures == nil {
+		failures = []FailedURL{} // Written as [] rather than null
+	}
+
+	return CrawlSummary{
+		Total:          s.total,
+		Succeeded:      s.succeeded,
+		Failed:         len(failures),
+		ElapsedSeconds: elapsed.Seconds(),
+		BytesFetched:   s.bytes,
+		StatusCodes:    maps.Clone(s.statuses),
+		FailedURLs:     failures,
+	}
+}
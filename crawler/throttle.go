@@ -0,0 +1,117 @@
+package crawler
+
+import (
+	"context"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// throttle spaces out requests to the same host, using either a fixed delay or
+// the host's robots.txt Crawl-delay.
+type throttle struct {
+	opts   Options
+	mu     sync.Mutex
+	delays map[string]*hostDelay // host -> delay between requests
+	next   map[string]time.Time  // host -> earliest time of the next request
+}
+
+// hostDelay is the delay between requests to a host, or one still being looked
+// up in the host's robots.txt until ready is closed.
+type hostDelay struct {
+	ready chan struct{}
+	delay time.Duration
+}
+
+// newThrottle creates a throttle for the delay settings in opts.
+func newThrottle(opts Options) *throttle {
+	return &throttle{
+		opts:   opts,
+		delays: make(map[string]*hostDelay),
+		next:   make(map[string]time.Time),
+	}
+}
+
+// wait blocks until a request to the host of pageURL is allowed, or ctx is done.
+// The first request to a host looks up its delay; concurrent requests to the same
+// host wait for that lookup, while requests to other hosts carry on meanwhile.
+func (t *throttle) wait(ctx context.Context, pageURL string) error {
+	parsedURL, err := url.Parse(pageURL)
+	if err != nil {
+		return nil
+	}
+	host := parsedURL.Host
+
+	t.mu.Lock()
+	entry, known := t.delays[host]
+	if !known {
+		entry = &hostDelay{ready: make(chan struct{})}
+		t.delays[host] = entry
+	}
+	t.mu.Unlock()
+
+	if !known {
+		entry.delay = t.lookupDelay(ctx, pageURL, host)
+		close(entry.ready)
+	} else {
+		select {
+		case <-entry.ready:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	if entry.delay <= 0 {
+		return nil
+	}
+
+	// Reserve the next free slot for this host
+	t.mu.Lock()
+	slot := t.next[host]
+	if now := time.Now(); slot.Before(now) {
+		slot = now
+	}
+	t.next[host] = slot.Add(entry.delay)
+	t.mu.Unlock()
+
+	timer := time.NewTimer(time.Until(slot))
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// lookupDelay returns the delay for host: its robots.txt Crawl-delay when
+// RobotsDelay is set, and the fixed Delay otherwise.
+func (t *throttle) lookupDelay(ctx context.Context, pageURL, host string) time.Duration {
+	if !t.opts.RobotsDelay {
+		return t.opts.Delay
+	}
+	metaCtx, cancel := metaContext(ctx, t.opts)
+	defer cancel()
+	delay := fetchCrawlDelay(metaCtx, pageURL)
+	if delay > 0 {
+		t.opts.debugf("Applying robots.txt Crawl-delay of %s for %s\n", delay, host)
+	}
+	return delay
+}
+
+// throttleKey is the context key under which a crawl passes its throttle to the
+// extra requests made for a page, such as those for its continuation pages.
+type throttleKey struct{}
+
+// withThrottle makes requests that call waitThrottle with ctx wait for t.
+func withThrottle(ctx context.Context, t *throttle) context.Context {
+	return context.WithValue(ctx, throttleKey{}, t)
+}
+
+// waitThrottle waits for the throttle of the crawl ctx belongs to, if any, before
+// a request to pageURL.
+func waitThrottle(ctx context.Context, pageURL string) error {
+	if t, ok := ctx.Value(throttleKey{}).(*throttle); ok {
+		return t.wait(ctx, pageURL)
+	}
+	return nil
+}
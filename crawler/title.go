@@ -0,0 +1,29 @@
+package crawler
+
+import "strings"
+
+// DefaultTitleSeparators are the separators commonly placed between a page title
+// and the site name, as in "Pricing | Example" or "Pricing - Example".
+var DefaultTitleSeparators = []string{" | ", " - ", " – ", " — ", " :: ", " · "}
+
+// stripTitleSuffix removes everything from the last separator in title onwards, so
+// "Pricing | Example" becomes "Pricing". Titles without a separator, or that would be
+// left empty, are returned unchanged.
+func stripTitleSuffix(title string, separators []string) string {
+	cut := -1
+	for _, separator := range separators {
+		if separator == "" {
+			continue
+		}
+		if i := strings.LastIndex(title, separator); i > cut {
+			cut = i
+		}
+	}
+	if cut <= 0 {
+		return title
+	}
+	if stripped := strings.TrimSpace(title[:cut]); stripped != "" {
+		return stripped
+	}
+	return title
+}
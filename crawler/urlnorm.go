@@ -0,0 +1,44 @@
+package crawler
+
+import (
+	"net/url"
+	"path"
+	"strings"
+)
+
+// Link normalization policies for Options.NormalizeLinks. Each removes URL
+// fragments; they differ in how trailing slashes are treated.
+const (
+	NormalizeFragments = "fragments" // Only remove fragments
+	NormalizeStrip     = "strip"     // Also remove trailing slashes: /docs/ becomes /docs
+	NormalizeAdd       = "add"       // Also add trailing slashes to paths that don't name a file: /docs becomes /docs/
+)
+
+// normalizeLink applies a NormalizeLinks policy to a URL, returning it unchanged
+// if the policy is empty or the URL can't be parsed.
+func normalizeLink(link, policy string) string {
+	if policy == "" {
+		return link
+	}
+	parsedURL, err := url.Parse(link)
+	if err != nil {
+		return link
+	}
+
+	parsedURL.Fragment = ""
+	parsedURL.RawFragment = ""
+	switch policy {
+	case NormalizeStrip:
+		if parsedURL.Path != "/" {
+			parsedURL.Path = strings.TrimRight(parsedURL.Path, "/")
+			parsedURL.RawPath = ""
+		}
+	case NormalizeAdd:
+		// Paths like /feed.xml name a file, which a slash would break
+		if !strings.HasSuffix(parsedURL.Path, "/") && path.Ext(parsedURL.Path) == "" {
+			parsedURL.Path += "/"
+			parsedURL.RawPath = ""
+		}
+	}
+	return parsedURL.String()
+}
@@ -0,0 +1,74 @@
+package crawler
+
+import (
+	"bufio"
+	"fmt"
+	"math/rand/v2"
+	"net/http"
+	"os"
+	"strings"
+	"sync/atomic"
+)
+
+// DefaultUserAgents are the built-in User-Agent presets for rotation: current
+// desktop and mobile browsers on the major platforms.
+var DefaultUserAgents = []string{
+	"Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/128.0.0.0 Safari/537.36",
+	"Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.6 Safari/605.1.15",
+	"Mozilla/5.0 (Windows NT 10.0; Win64; x64; rv:130.0) Gecko/20100101 Firefox/130.0",
+	"Mozilla/5.0 (X11; Linux x86_64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/128.0.0.0 Safari/537.36",
+	"Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/128.0.0.0 Safari/537.36 Edg/128.0.0.0",
+	"Mozilla/5.0 (iPhone; CPU iPhone OS 17_6 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.6 Mobile/15E148 Safari/604.1",
+	"Mozilla/5.0 (Linux; Android 14; Pixel 8) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/128.0.0.0 Mobile Safari/537.36",
+}
+
+// LoadUserAgents reads User-Agent strings from a file, one per line. Blank lines
+// and lines starting with # are skipped.
+func LoadUserAgents(path string) ([]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("error opening user agent file %s: %w", path, err)
+	}
+	defer file.Close()
+
+	var agents []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		agents = append(agents, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error reading user agent file %s: %w", path, err)
+	}
+	if len(agents) == 0 {
+		return nil, fmt.Errorf("user agent file %s has no user agents", path)
+	}
+	return agents, nil
+}
+
+// userAgentTransport sets the User-Agent of each request, taking the agents in
+// turn or, when random is set, picking one at random per request.
+type userAgentTransport struct {
+	base   http.RoundTripper
+	agents []string
+	random bool
+	next   atomic.Uint64
+}
+
+// RoundTrip sends the request with the next User-Agent. The request is cloned
+// first, since a RoundTripper must not modify the one it is given.
+func (t *userAgentTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	var agent string
+	if t.random {
+		agent = t.agents[rand.IntN(len(t.agents))]
+	} else {
+		agent = t.agents[(t.next.Add(1)-1)%uint64(len(t.agents))]
+	}
+
+	req = req.Clone(req.Context())
+	req.Header.Set("User-Agent", agent)
+	return t.base.RoundTrip(req)
+}
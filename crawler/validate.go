@@ -0,0 +1,128 @@
+package crawler
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// Limits on a single sitemap file from the sitemaps.org protocol.
+const (
+	maxSitemapURLs  = 50000
+	maxSitemapBytes = 50 * 1024 * 1024 // Uncompressed
+	maxLocLength    = 2048
+)
+
+// ValidationReport lists the problems found in a sitemap.
+type ValidationReport struct {
+	Root     string   // Root element: urlset or sitemapindex
+	URLs     int      // Number of <url> entries
+	Sitemaps int      // Number of <sitemap> entries
+	Bytes    int64    // Uncompressed size
+	Issues   []string // Empty when the sitemap is valid
+}
+
+// Valid reports whether no issues were found.
+func (r *ValidationReport) Valid() bool {
+	return len(r.Issues) == 0
+}
+
+func (r *ValidationReport) addIssue(format string, args ...any) {
+	r.Issues = append(r.Issues, fmt.Sprintf(format, args...))
+}
+
+// validatedSitemap is a Sitemap that also records its root element.
+type validatedSitemap struct {
+	XMLName xml.Name
+	Sitemap
+}
+
+// ValidateSitemap fetches (or opens) a sitemap and checks it against the sitemap
+// protocol without crawling it: the root element, a valid absolute <loc> in every
+// entry, parseable <lastmod> dates, and the 50,000 URL / 50MB limits. An error is
+// only returned when the sitemap can't be read or isn't well-formed XML.
+func ValidateSitemap(ctx context.Context, sitemapURL string, opts Options) (*ValidationReport, error) {
+	body, err := openFeed(ctx, sitemapURL, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch sitemap: %w", err)
+	}
+	defer body.Close()
+
+	counter := &countingReader{r: body}
+	var sitemap validatedSitemap
+	if err := xml.NewDecoder(counter).Decode(&sitemap); err != nil {
+		return nil, fmt.Errorf("error parsing sitemap: %w", err)
+	}
+
+	report := &ValidationReport{
+		Root:     sitemap.XMLName.Local,
+		URLs:     len(sitemap.URLs),
+		Sitemaps: len(sitemap.Sitemaps),
+		Bytes:    counter.n,
+	}
+
+	switch report.Root {
+	case "urlset":
+		if report.Sitemaps > 0 {
+			report.addIssue("urlset contains %d <sitemap> entries, which belong in a sitemapindex", report.Sitemaps)
+		}
+	case "sitemapindex":
+		if report.URLs > 0 {
+			report.addIssue("sitemapindex contains %d <url> entries, which belong in a urlset", report.URLs)
+		}
+	default:
+		report.addIssue("root element is <%s>, expected <urlset> or <sitemapindex>", report.Root)
+	}
+
+	if report.URLs == 0 && report.Sitemaps == 0 {
+		report.addIssue("sitemap has no <url> or <sitemap> entries")
+	}
+	if report.URLs > maxSitemapURLs {
+		report.addIssue("sitemap has %d URLs, more than the limit of %d", report.URLs, maxSitemapURLs)
+	}
+	if report.Sitemaps > maxSitemapURLs {
+		report.addIssue("sitemap index has %d sitemaps, more than the limit of %d", report.Sitemaps, maxSitemapURLs)
+	}
+	if report.Bytes > maxSitemapBytes {
+		report.addIssue("sitemap is %d bytes uncompressed, more than the limit of %d", report.Bytes, maxSitemapBytes)
+	}
+
+	for i, u := range sitemap.URLs {
+		validateEntry(report, fmt.Sprintf("<url> %d", i+1), u)
+	}
+	for i, s := range sitemap.Sitemaps {
+		validateEntry(report, fmt.Sprintf("<sitemap> %d", i+1), s)
+	}
+
+	return report, nil
+}
+
+// validateEntry checks the <loc> and <lastmod> of one sitemap entry.
+func validateEntry(report *ValidationReport, name string, u SitemapURL) {
+	loc := strings.TrimSpace(u.Loc)
+	if loc == "" {
+		report.addIssue("%s: missing <loc>", name)
+		return
+	}
+
+	if len(loc) > maxLocLength {
+		report.addIssue("%s: <loc> is %d characters, more than the limit of %d", name, len(loc), maxLocLength)
+	}
+	parsedURL, err := url.Parse(loc)
+	switch {
+	case err != nil:
+		report.addIssue("%s: invalid <loc> %q: %v", name, loc, err)
+	case parsedURL.Scheme != "http" && parsedURL.Scheme != "https":
+		report.addIssue("%s: <loc> %q is not an absolute http(s) URL", name, loc)
+	case parsedURL.Host == "":
+		report.addIssue("%s: <loc> %q has no host", name, loc)
+	}
+
+	if lastMod := strings.TrimSpace(u.LastMod); lastMod != "" {
+		if _, ok := parseDate(lastMod); !ok {
+			report.addIssue("%s: <lastmod> %q is not a valid date", name, lastMod)
+		}
+	}
+}
@@ -0,0 +1,86 @@
+package crawler
+
+import (
+	"math/rand"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+// regexpRemoveExcessNewlines is the regexp-based removeExcessNewlines that the
+// single-pass version replaced, kept to check that the output is unchanged.
+func regexpRemoveExcessNewlines(content string) string {
+	content = strings.ReplaceAll(content, "\r\n", "\n")
+	content = strings.ReplaceAll(content, "\r", "\n")
+	content = regexp.MustCompile(`\s{2,}`).ReplaceAllString(content, " ")
+	return regexp.MustCompile(`\n{3,}`).ReplaceAllString(content, "\n\n")
+}
+
+func TestRemoveExcessNewlines(t *testing.T) {
+	tests := []struct {
+		name, content, want string
+	}{
+		{"empty", "", ""},
+		{"plain", "one two", "one two"},
+		{"space run", "one   two", "one two"},
+		{"mixed run", "one \t\n two", "one two"},
+		{"lone newline", "one\ntwo", "one\ntwo"},
+		{"lone tab", "one\ttwo", "one\ttwo"},
+		{"blank line", "one\n\ntwo", "one two"},
+		{"crlf", "one\r\ntwo", "one\ntwo"},
+		{"lone cr", "one\rtwo", "one\ntwo"},
+		{"crlf run", "one\r\n\r\ntwo", "one two"},
+		{"cr then lf", "one\r\r\ntwo", "one two"},
+		{"trailing whitespace", "one  \ntwo \t", "one two "},
+		{"trailing newline", "one\n", "one\n"},
+		{"leading whitespace", "\n\n  one", " one"},
+		{"only blank lines", "\n\n\n", " "},
+		{"only spaces and tabs", " \t \t", " "},
+		{"only crlf", "\r\n", "\n"},
+		{"form feed", "one\f\ftwo", "one two"},
+		{"vertical tab kept", "one\v\vtwo", "one\v\vtwo"},
+		{"non-breaking space kept", "one\u00a0\u00a0two", "one\u00a0\u00a0two"},
+		{"multibyte text", "déjà  vu\r\n", "déjà vu\n"},
+	}
+	for _, tt := range tests {
+		got := removeExcessNewlines(tt.content)
+		if got != tt.want {
+			t.Errorf("%s: removeExcessNewlines(%q) = %q, want %q", tt.name, tt.content, got, tt.want)
+		}
+		if old := regexpRemoveExcessNewlines(tt.content); got != old {
+			t.Errorf("%s: removeExcessNewlines(%q) = %q, the regexp version gave %q", tt.name, tt.content, got, old)
+		}
+	}
+}
+
+func TestRemoveExcessNewlinesMatchesRegexp(t *testing.T) {
+	pieces := []string{"a", "word", "é", " ", "  ", "\t", "\n", "\r", "\r\n", "\f", "\v", "\u00a0"}
+	random := rand.New(rand.NewSource(1))
+	for i := 0; i < 5000; i++ {
+		var content strings.Builder
+		for n := random.Intn(12); n > 0; n-- {
+			content.WriteString(pieces[random.Intn(len(pieces))])
+		}
+		if got, want := removeExcessNewlines(content.String()), regexpRemoveExcessNewlines(content.String()); got != want {
+			t.Fatalf("removeExcessNewlines(%q) = %q, the regexp version gave %q", content.String(), got, want)
+		}
+	}
+}
+
+// whitespaceBenchmarkContent is about 1 MB of text with the line breaks and
+// indentation a converted page typically has.
+var whitespaceBenchmarkContent = strings.Repeat("Some words in a paragraph,  with   runs of spaces.\r\n\r\n\t\tIndented line\n\n\n", 12000)
+
+func BenchmarkRemoveExcessNewlines(b *testing.B) {
+	b.SetBytes(int64(len(whitespaceBenchmarkContent)))
+	for i := 0; i < b.N; i++ {
+		removeExcessNewlines(whitespaceBenchmarkContent)
+	}
+}
+
+func BenchmarkRemoveExcessNewlinesRegexp(b *testing.B) {
+	b.SetBytes(int64(len(whitespaceBenchmarkContent)))
+	for i := 0; i < b.N; i++ {
+		regexpRemoveExcessNewlines(whitespaceBenchmarkContent)
+	}
+}
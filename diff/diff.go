@@ -0,0 +1,127 @@
+// Package diff compares a crawl against a previous export to find the pages that
+// were added, removed, or changed in between.
+package diff
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sitemapExport/crawler"
+	"sort"
+)
+
+// maxLineBytes bounds a single jsonl line, since pages can be far longer than
+// bufio.Scanner's 64 KiB default.
+const maxLineBytes = 64 * 1024 * 1024
+
+// Report lists the URLs that differ between two crawls, each in sorted order.
+type Report struct {
+	Added     []string // Pages only in the new crawl
+	Removed   []string // Pages only in the previous export
+	Changed   []string // Pages whose content hash differs
+	Unchanged int
+}
+
+// LoadPages reads the pages of a previous json or jsonl export.
+func LoadPages(path string) ([]crawler.Page, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading previous export %s: %w", path, err)
+	}
+
+	// A json export is a single array, or an object with Sites and Pages when
+	// written with --site-meta; anything else is read as jsonl, one page per line
+	trimmed := bytes.TrimSpace(data)
+	if bytes.HasPrefix(trimmed, []byte("[")) {
+		var pages []crawler.Page
+		if err := json.Unmarshal(trimmed, &pages); err != nil {
+			return nil, fmt.Errorf("error parsing previous export %s: %w", path, err)
+		}
+		return pages, nil
+	}
+	var envelope struct {
+		Sites json.RawMessage
+		Pages []crawler.Page
+	}
+	if json.Unmarshal(trimmed, &envelope) == nil && envelope.Sites != nil {
+		return envelope.Pages, nil
+	}
+
+	var pages []crawler.Page
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(nil, maxLineBytes)
+	for line := 1; scanner.Scan(); line++ {
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+		var page crawler.Page
+		if err := json.Unmarshal(scanner.Bytes(), &page); err != nil {
+			return nil, fmt.Errorf("error parsing previous export %s line %d: %w", path, line, err)
+		}
+		pages = append(pages, page)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error reading previous export %s: %w", path, err)
+	}
+	return pages, nil
+}
+
+// Compare matches pages by URL and compares their content hashes. Whitespace and
+// case differences don't count as changes.
+func Compare(previous, current []crawler.Page) Report {
+	previousHashes := make(map[string]string, len(previous))
+	for _, page := range previous {
+		previousHashes[page.URL] = crawler.ContentHash(page.Content)
+	}
+
+	var report Report
+	seen := make(map[string]bool, len(current))
+	for _, page := range current {
+		if seen[page.URL] {
+			continue
+		}
+		seen[page.URL] = true
+
+		previousHash, existed := previousHashes[page.URL]
+		switch {
+		case !existed:
+			report.Added = append(report.Added, page.URL)
+		case previousHash != crawler.ContentHash(page.Content):
+			report.Changed = append(report.Changed, page.URL)
+		default:
+			report.Unchanged++
+		}
+	}
+	for pageURL := range previousHashes {
+		if !seen[pageURL] {
+			report.Removed = append(report.Removed, pageURL)
+		}
+	}
+
+	sort.Strings(report.Added)
+	sort.Strings(report.Removed)
+	sort.Strings(report.Changed)
+	return report
+}
+
+// FilterChanged returns the pages that were added or changed in the report,
+// keeping their order.
+func FilterChanged(pages []crawler.Page, report Report) []crawler.Page {
+	keep := make(map[string]bool, len(report.Added)+len(report.Changed))
+	for _, pageURL := range report.Added {
+		keep[pageURL] = true
+	}
+	for _, pageURL := range report.Changed {
+		keep[pageURL] = true
+	}
+
+	var filtered []crawler.Page
+	for _, page := range pages {
+		if keep[page.URL] {
+			filtered = append(filtered, page)
+		}
+	}
+	return filtered
+}
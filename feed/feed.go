@@ -1,20 +1,41 @@
 package feed
 
 import (
+	"bufio"
+	"compress/gzip"
 	"encoding/xml"
 	"fmt"
+	"io"
+	"mime"
 	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
 	"time"
 )
 
-// DetectFeedType detects whether the URL is an RSS feed or sitemap based on the XML root element.
+// Client is the HTTP client used to fetch the feed for detection. Its timeout is
+// kept short so an unresponsive feed URL fails fast.
+var Client = &http.Client{
+	Timeout: 10 * time.Second,
+}
+
+// DetectFeedType detects whether the URL (or local file path) is an RSS feed,
+// sitemap, or OPML list of feeds based on the XML root element.
 func DetectFeedType(feedURL string) (string, error) {
-	client := &http.Client{
-		Timeout: 10 * time.Second, // Set a timeout to avoid long-running requests
+	if IsLocal(feedURL) {
+		file, err := OpenFile(feedURL)
+		if err != nil {
+			return "", err
+		}
+		defer file.Close()
+		return detectRoot(file, feedURL)
 	}
 
 	// Fetch the feed URL
-	res, err := client.Get(feedURL)
+	res, err := Client.Get(feedURL)
 	if err != nil {
 		return "", fmt.Errorf("error fetching URL %s: %w", feedURL, err)
 	}
@@ -25,11 +46,46 @@ func DetectFeedType(feedURL string) (string, error) {
 		return "", fmt.Errorf("unexpected HTTP status: %d %s", res.StatusCode, http.StatusText(res.StatusCode))
 	}
 
-	// Parse the XML root element to detect the feed type
+	body, err := NewReader(res.Body)
+	if err != nil {
+		return "", fmt.Errorf("error reading %s: %w", feedURL, err)
+	}
+
+	// A moved sitemap often redirects to an HTML landing page, which would
+	// otherwise only surface as an XML syntax error
+	buffered := bufio.NewReader(body)
+	if isHTML(res.Header.Get("Content-Type"), buffered) {
+		if finalURL := res.Request.URL.String(); finalURL != feedURL {
+			return "", fmt.Errorf("%s redirected to an HTML page at %s instead of a sitemap or RSS feed; the feed may have moved", feedURL, finalURL)
+		}
+		return "", fmt.Errorf("%s is an HTML page, not a sitemap or RSS feed", feedURL)
+	}
+	return detectRoot(buffered, feedURL)
+}
+
+// isHTML reports whether a response is an HTML page, judging by its Content-Type
+// or, since servers often label feeds loosely, by how its content starts.
+func isHTML(contentType string, body *bufio.Reader) bool {
+	if mediaType, _, err := mime.ParseMediaType(contentType); err == nil && mediaType == "text/html" {
+		return true
+	}
+	start, _ := body.Peek(512)
+	text := strings.ToLower(strings.TrimSpace(string(start)))
+	return strings.HasPrefix(text, "<!doctype html") || strings.HasPrefix(text, "<html")
+}
+
+// detectRoot parses the XML root element to detect the feed type. robots.txt
+// files are recognized by name or, failing that, by their plain-text directives.
+func detectRoot(body io.Reader, feedURL string) (string, error) {
+	buffered := bufio.NewReader(body)
+	if isRobotsTxt(feedURL, buffered) {
+		return "robots", nil
+	}
+
 	var root struct {
 		XMLName xml.Name
 	}
-	decoder := xml.NewDecoder(res.Body)
+	decoder := xml.NewDecoder(buffered)
 	if err := decoder.Decode(&root); err != nil {
 		return "", fmt.Errorf("error decoding XML from %s: %w", feedURL, err)
 	}
@@ -38,9 +94,81 @@ func DetectFeedType(feedURL string) (string, error) {
 	switch root.XMLName.Local {
 	case "urlset":
 		return "sitemap", nil
+	case "sitemapindex":
+		return "sitemapindex", nil
 	case "rss":
 		return "rss", nil
+	case "opml":
+		return "opml", nil
 	default:
 		return "", fmt.Errorf("unknown feed type for URL %s", feedURL)
 	}
 }
+
+// isRobotsTxt reports whether the feed is a robots.txt file, judging by its name or
+// by content that isn't XML but has User-agent or Sitemap directives.
+func isRobotsTxt(feedURL string, body *bufio.Reader) bool {
+	name := feedURL
+	if parsedURL, err := url.Parse(feedURL); err == nil && parsedURL.Path != "" {
+		name = parsedURL.Path
+	}
+	if strings.EqualFold(path.Base(filepath.ToSlash(name)), "robots.txt") {
+		return true
+	}
+
+	start, _ := body.Peek(512)
+	text := strings.ToLower(strings.TrimSpace(string(start)))
+	if strings.HasPrefix(text, "<") {
+		return false
+	}
+	return strings.Contains(text, "user-agent:") || strings.Contains(text, "sitemap:")
+}
+
+// IsLocal reports whether location is a local file path rather than an http(s) URL.
+func IsLocal(location string) bool {
+	parsedURL, err := url.Parse(location)
+	return err != nil || (parsedURL.Scheme != "http" && parsedURL.Scheme != "https")
+}
+
+// OpenFile opens a local feed file, such as a downloaded sitemap. Files with a .gz
+// extension are gunzipped; others are still checked for the gzip magic bytes.
+func OpenFile(path string) (io.ReadCloser, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("error opening file %s: %w", path, err)
+	}
+
+	var body io.Reader
+	if strings.EqualFold(filepath.Ext(path), ".gz") {
+		body, err = gzip.NewReader(file)
+	} else {
+		body, err = NewReader(file)
+	}
+	if err != nil {
+		file.Close()
+		return nil, fmt.Errorf("error reading %s: %w", path, err)
+	}
+	return &fileReader{Reader: body, file: file}, nil
+}
+
+// fileReader reads a (possibly decompressed) feed file and closes the file when done.
+type fileReader struct {
+	io.Reader
+	file *os.File
+}
+
+// Close closes the underlying file.
+func (f *fileReader) Close() error {
+	return f.file.Close()
+}
+
+// NewReader returns a reader that transparently decompresses gzipped content
+// (e.g. sitemap.xml.gz files), detected by the gzip magic bytes.
+func NewReader(r io.Reader) (io.Reader, error) {
+	buffered := bufio.NewReader(r)
+	magic, err := buffered.Peek(2)
+	if err == nil && magic[0] == 0x1f && magic[1] == 0x8b {
+		return gzip.NewReader(buffered)
+	}
+	return buffered, nil
+}
@@ -0,0 +1,160 @@
+package formatter
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sitemapExport/crawler"
+	"strconv"
+	"strings"
+)
+
+// flatListSeparator joins the values of a flattened list, such as Tags.
+const flatListSeparator = ", "
+
+// flatPage is a page flattened to a single level of fields, encoded in field order.
+type flatPage []flatField
+
+// flatField is one flattened key and its value: a string, number, bool, or null.
+type flatField struct {
+	key   string
+	value json.RawMessage
+}
+
+// MarshalJSON encodes the fields as a JSON object, keeping their order.
+func (p flatPage) MarshalJSON() ([]byte, error) {
+	var buffer bytes.Buffer
+	buffer.WriteByte('{')
+	for i, field := range p {
+		if i > 0 {
+			buffer.WriteByte(',')
+		}
+		key, err := json.Marshal(field.key)
+		if err != nil {
+			return nil, err
+		}
+		buffer.Write(key)
+		buffer.WriteByte(':')
+		buffer.Write(field.value)
+	}
+	buffer.WriteByte('}')
+	return buffer.Bytes(), nil
+}
+
+// flattenPages flattens each page for tools that don't handle nested JSON: lists of
+// values (like Tags) are joined into one string, and objects and lists of objects
+// become dotted keys such as HTTP.StatusCode and Alternates.0.Href.
+func flattenPages(pages []crawler.Page) ([]flatPage, error) {
+	flat := make([]flatPage, 0, len(pages))
+	for _, page := range pages {
+		data, err := json.Marshal(page)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal JSON: %w", err)
+		}
+		// Walk the page's own JSON, so field names and omitempty match the regular output
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.UseNumber()
+		var fields flatPage
+		if err := flattenValue(decoder, "", &fields); err != nil {
+			return nil, fmt.Errorf("failed to flatten JSON: %w", err)
+		}
+		flat = append(flat, fields)
+	}
+	return flat, nil
+}
+
+// flattenValue reads the next JSON value from decoder, appending its fields
+// under prefix.
+func flattenValue(decoder *json.Decoder, prefix string, fields *flatPage) error {
+	token, err := decoder.Token()
+	if err != nil {
+		return err
+	}
+	return flattenToken(decoder, token, prefix, fields)
+}
+
+// flattenToken flattens the JSON value starting with token, reading the rest of
+// an object or array from decoder.
+func flattenToken(decoder *json.Decoder, token json.Token, prefix string, fields *flatPage) error {
+	switch token {
+	case json.Delim('{'):
+		for decoder.More() {
+			key, err := decoder.Token()
+			if err != nil {
+				return err
+			}
+			if err := flattenValue(decoder, joinKey(prefix, key.(string)), fields); err != nil {
+				return err
+			}
+		}
+		_, err := decoder.Token() // Closing }
+		return err
+	case json.Delim('['):
+		return flattenList(decoder, prefix, fields)
+	default:
+		return appendScalar(fields, prefix, token)
+	}
+}
+
+// flattenList flattens the rest of a JSON array. A list of plain values becomes one
+// joined string; a list containing objects or lists is flattened by index instead.
+func flattenList(decoder *json.Decoder, prefix string, fields *flatPage) error {
+	var values []string
+	var items flatPage
+	for i := 0; decoder.More(); i++ {
+		token, err := decoder.Token()
+		if err != nil {
+			return err
+		}
+		if err := flattenToken(decoder, token, joinKey(prefix, strconv.Itoa(i)), &items); err != nil {
+			return err
+		}
+		if _, isDelim := token.(json.Delim); !isDelim {
+			values = append(values, scalarString(token))
+		}
+	}
+	if _, err := decoder.Token(); err != nil { // Closing ]
+		return err
+	}
+
+	if len(values) == len(items) {
+		joined, err := json.Marshal(strings.Join(values, flatListSeparator))
+		if err != nil {
+			return err
+		}
+		*fields = append(*fields, flatField{key: prefix, value: joined})
+		return nil
+	}
+	*fields = append(*fields, items...)
+	return nil
+}
+
+// appendScalar appends a plain JSON value as a field.
+func appendScalar(fields *flatPage, key string, token json.Token) error {
+	value, err := json.Marshal(token)
+	if err != nil {
+		return err
+	}
+	*fields = append(*fields, flatField{key: key, value: value})
+	return nil
+}
+
+// scalarString formats a plain JSON value for joining into a flattened list.
+func scalarString(token json.Token) string {
+	switch value := token.(type) {
+	case string:
+		return value
+	case nil:
+		return ""
+	default:
+		return fmt.Sprint(value)
+	}
+}
+
+// joinKey adds key to a dotted prefix.
+func joinKey(prefix, key string) string {
+	if prefix == "" {
+		return key
+	}
+	return prefix + "." + key
+}
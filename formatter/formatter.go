@@ -5,25 +5,80 @@ import (
 	"encoding/json"
 	"fmt"
 	"sitemapExport/crawler"
+	"strings"
 )
 
-// FormatPages formats pages based on the selected format (json, jsonl, txt, md, pdf).
+// DefaultPageSeparator is written after each page in text-based output.
+const DefaultPageSeparator = "\n\n----------------------------------------------\n" +
+	"----------------------------------------------\n\n"
+
+// Options controls the layout of text-based (txt, md, pdf) and tsv output.
+type Options struct {
+	OnlyContent     bool   // Emit just each page's content, without the metadata header and separators
+	PageSeparator   string // Written after each page instead of DefaultPageSeparator (or a blank line with OnlyContent), if set
+	TSVContentLimit int    // Truncate Content in tsv output to this many characters, if set
+	FlattenJSON     bool   // Flatten json and jsonl pages to one level, joining lists and dotting nested keys
+	MarkdownTOC     bool   // Start md output with a table of contents linking to each page's heading
+	CollapseContent bool   // Collapse whitespace runs in Content to single spaces in jsonl and tsv output
+
+	// Templates, if set, replaces the built-in txt and md layout.
+	Templates *Templates
+}
+
+// FormatPages formats pages based on the selected format (json, jsonl, tsv, txt, md, pdf).
 // It returns the formatted string or an error if the format is unsupported.
-func FormatPages(pages []crawler.Page, format string) (string, error) {
+func FormatPages(pages []crawler.Page, format string, opts Options) (string, error) {
 	switch format {
-	case "json":
-		return formatJSON(pages)
-	case "jsonl":
-		return formatJSONLines(pages)
-	case "txt", "md", "pdf": // Text-based formats are handled together
-		return formatTextBased(pages)
+	case "json", "jsonl":
+		if format == "jsonl" && opts.CollapseContent {
+			pages = collapseContent(pages)
+		}
+		if opts.FlattenJSON {
+			flat, err := flattenPages(pages)
+			if err != nil {
+				return "", err
+			}
+			return formatJSONRecords(flat, format)
+		}
+		return formatJSONRecords(pages, format)
+	case "tsv":
+		return formatTSV(pages, opts)
+	case "md":
+		if opts.MarkdownTOC {
+			var buffer bytes.Buffer
+			writeMarkdownTOC(&buffer, pages)
+			content, err := formatTextBased(pages, opts)
+			return buffer.String() + content, err
+		}
+		return formatTextBased(pages, opts)
+	case "txt", "pdf": // Text-based formats are handled together
+		return formatTextBased(pages, opts)
 	default:
 		return "", fmt.Errorf("unsupported format: %s", format)
 	}
 }
 
+// collapseContent returns a copy of pages with each run of whitespace in their
+// content, newlines included, replaced by a single space, for greppable lines.
+func collapseContent(pages []crawler.Page) []crawler.Page {
+	collapsed := make([]crawler.Page, len(pages))
+	for i, page := range pages {
+		page.Content = strings.Join(strings.Fields(page.Content), " ")
+		collapsed[i] = page
+	}
+	return collapsed
+}
+
+// formatJSONRecords formats pages (either as is or flattened) as json or jsonl.
+func formatJSONRecords[T any](records []T, format string) (string, error) {
+	if format == "jsonl" {
+		return formatJSONLines(records)
+	}
+	return formatJSON(records)
+}
+
 // formatJSON formats the pages as pretty-printed JSON.
-func formatJSON(pages []crawler.Page) (string, error) {
+func formatJSON[T any](pages []T) (string, error) {
 	data, err := json.MarshalIndent(pages, "", "  ")
 	if err != nil {
 		return "", fmt.Errorf("failed to marshal JSON: %w", err)
@@ -31,8 +86,54 @@ func formatJSON(pages []crawler.Page) (string, error) {
 	return string(data), nil
 }
 
+// FormatJSONArrayElement formats one page as it appears inside json output: an
+// object indented one level, without a trailing comma or newline. It lets a json
+// array be written a page at a time.
+func FormatJSONArrayElement(page crawler.Page, opts Options) (string, error) {
+	var record any = page
+	if opts.FlattenJSON {
+		flat, err := flattenPages([]crawler.Page{page})
+		if err != nil {
+			return "", err
+		}
+		record = flat[0]
+	}
+	data, err := json.MarshalIndent(record, "  ", "  ")
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal JSON: %w", err)
+	}
+	return "  " + string(data), nil
+}
+
+// jsonEnvelope is the JSON output shape when crawl-level metadata is included.
+type jsonEnvelope struct {
+	Sites []crawler.SiteMeta `json:"Sites"`
+	Pages any                `json:"Pages"` // []crawler.Page, or []flatPage with FlattenJSON
+}
+
+// FormatJSONEnvelope formats the pages as pretty-printed JSON wrapped in an object
+// that also lists the sites they came from.
+func FormatJSONEnvelope(pages []crawler.Page, sites []crawler.SiteMeta, opts Options) (string, error) {
+	envelope := jsonEnvelope{Sites: sites, Pages: pages}
+	if opts.FlattenJSON {
+		flat, err := flattenPages(pages)
+		if err != nil {
+			return "", err
+		}
+		envelope.Pages = flat
+	}
+	if pages == nil {
+		envelope.Pages = []crawler.Page{} // Keep "Pages" an array even when nothing was crawled
+	}
+	data, err := json.MarshalIndent(envelope, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal JSON: %w", err)
+	}
+	return string(data), nil
+}
+
 // formatJSONLines formats each page as a single JSON object per line (JSONL format).
-func formatJSONLines(pages []crawler.Page) (string, error) {
+func formatJSONLines[T any](pages []T) (string, error) {
 	var buffer bytes.Buffer
 	for _, page := range pages {
 		data, err := json.Marshal(page)
@@ -47,16 +148,55 @@ func formatJSONLines(pages []crawler.Page) (string, error) {
 
 // formatTextBased formats the pages as text-based output (txt, md, pdf).
 // The same format is used for all these cases as plain text.
-func formatTextBased(pages []crawler.Page) (string, error) {
+func formatTextBased(pages []crawler.Page, opts Options) (string, error) {
+	if opts.Templates != nil {
+		return opts.Templates.render(pages)
+	}
+
 	var buffer bytes.Buffer
 	for _, page := range pages {
-		// Writing directly to buffer with fmt.Fprint instead of fmt.Sprintf
-		fmt.Fprintf(&buffer, "# %s\n", page.Title)
-		fmt.Fprintf(&buffer, "URL: %s\n", page.URL)
-		fmt.Fprintf(&buffer, "Description: %s\n", page.Description)
-		fmt.Fprintf(&buffer, "Content:\n%s\n", page.Content)
-		buffer.WriteString("\n\n----------------------------------------------\n")
-		buffer.WriteString("----------------------------------------------\n\n")
+		writeTextPage(&buffer, page, opts)
 	}
 	return buffer.String(), nil
 }
+
+// FormatPage formats a single page as a text-based block, as used for txt, md, and pdf output.
+func FormatPage(page crawler.Page, opts Options) string {
+	var buffer bytes.Buffer
+	writeTextPage(&buffer, page, opts)
+	return buffer.String()
+}
+
+// writeTextPage writes the text-based block for one page, followed by the page separator.
+// With OnlyContent, just the content is written, followed by a blank line by default.
+func writeTextPage(buffer *bytes.Buffer, page crawler.Page, opts Options) {
+	if opts.OnlyContent {
+		buffer.WriteString(strings.Trim(page.Content, "\n"))
+		if opts.PageSeparator != "" {
+			buffer.WriteString("\n" + opts.PageSeparator)
+		} else {
+			buffer.WriteString("\n\n")
+		}
+		return
+	}
+
+	// Writing directly to buffer with fmt.Fprint instead of fmt.Sprintf
+	fmt.Fprintf(buffer, "# %s\n", page.Title)
+	fmt.Fprintf(buffer, "URL: %s\n", page.URL)
+	writeOptionalField(buffer, "Description", page.Description)
+	fmt.Fprintf(buffer, "Content:\n%s\n", page.Content)
+	if opts.PageSeparator != "" {
+		buffer.WriteString(opts.PageSeparator)
+	} else {
+		buffer.WriteString(DefaultPageSeparator)
+	}
+}
+
+// writeOptionalField writes a "Name: value" header line, leaving it out when the
+// value is empty, as omitempty does for JSON.
+func writeOptionalField(buffer *bytes.Buffer, name, value string) {
+	if value == "" {
+		return
+	}
+	fmt.Fprintf(buffer, "%s: %s\n", name, value)
+}
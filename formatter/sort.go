@@ -0,0 +1,39 @@
+package formatter
+
+import (
+	"fmt"
+	"sitemapExport/crawler"
+	"sort"
+	"strings"
+	"time"
+)
+
+// SortPages orders pages in place by "sitemap" (the order of the feed, which pages
+// are already in), "title", "url", or "date" (oldest first). Ties keep feed order,
+// and pages without a parseable date sort last.
+func SortPages(pages []crawler.Page, by string) error {
+	switch by {
+	case "", "sitemap":
+		return nil
+	case "title":
+		sort.SliceStable(pages, func(i, j int) bool {
+			return strings.ToLower(pages[i].Title) < strings.ToLower(pages[j].Title)
+		})
+	case "url":
+		sort.SliceStable(pages, func(i, j int) bool {
+			return pages[i].URL < pages[j].URL
+		})
+	case "date":
+		sort.SliceStable(pages, func(i, j int) bool {
+			a, aErr := time.Parse(time.RFC3339, pages[i].Date)
+			b, bErr := time.Parse(time.RFC3339, pages[j].Date)
+			if aErr != nil || bErr != nil {
+				return aErr == nil && bErr != nil // Dated pages before undated ones
+			}
+			return a.Before(b)
+		})
+	default:
+		return fmt.Errorf("unsupported sort order: %s", by)
+	}
+	return nil
+}
@@ -0,0 +1,90 @@
+package formatter
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sitemapExport/crawler"
+	"text/template"
+)
+
+// Template file names looked up in a template directory. Only the page template
+// is required.
+const (
+	HeaderTemplate = "header.tmpl"
+	PageTemplate   = "page.tmpl"
+	FooterTemplate = "footer.tmpl"
+)
+
+// Templates lays out txt and md output with user-supplied text/template files: the
+// header once at the start of the document, the page template for each page, and
+// the footer once at the end. The files are parsed as one set, so blocks defined
+// in any of them can be used from the others.
+type Templates struct {
+	set    *template.Template
+	header bool
+	footer bool
+}
+
+// TemplateDocument is the data passed to the header and footer templates.
+type TemplateDocument struct {
+	Pages []crawler.Page
+}
+
+// TemplatePage is the data passed to the page template: the page's fields, plus
+// its 1-based position in the document.
+type TemplatePage struct {
+	crawler.Page
+	Number int
+}
+
+// LoadTemplates parses the header, page, and footer templates in dir.
+func LoadTemplates(dir string) (*Templates, error) {
+	var files []string
+	found := make(map[string]bool)
+	for _, name := range []string{HeaderTemplate, PageTemplate, FooterTemplate} {
+		path := filepath.Join(dir, name)
+		if _, err := os.Stat(path); err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				continue
+			}
+			return nil, fmt.Errorf("error reading template %s: %w", path, err)
+		}
+		files = append(files, path)
+		found[name] = true
+	}
+	if !found[PageTemplate] {
+		return nil, fmt.Errorf("template directory %s has no %s", dir, PageTemplate)
+	}
+
+	set, err := template.ParseFiles(files...)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing templates in %s: %w", dir, err)
+	}
+	return &Templates{set: set, header: found[HeaderTemplate], footer: found[FooterTemplate]}, nil
+}
+
+// render lays out the pages with the templates.
+func (t *Templates) render(pages []crawler.Page) (string, error) {
+	var buffer bytes.Buffer
+	document := TemplateDocument{Pages: pages}
+	if t.header {
+		if err := t.set.ExecuteTemplate(&buffer, HeaderTemplate, document); err != nil {
+			return "", fmt.Errorf("error executing %s: %w", HeaderTemplate, err)
+		}
+	}
+	for i, page := range pages {
+		if err := t.set.ExecuteTemplate(&buffer, PageTemplate, TemplatePage{Page: page, Number: i + 1}); err != nil {
+			return "", fmt.Errorf("error executing %s for %s: %w", PageTemplate, page.URL, err)
+		}
+	}
+	if t.footer {
+		if err := t.set.ExecuteTemplate(&buffer, FooterTemplate, document); err != nil {
+			return "", fmt.Errorf("error executing %s: %w", FooterTemplate, err)
+		}
+	}
+	return buffer.String(), nil
+}
@@ -0,0 +1,122 @@
+package formatter
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"sitemapExport/crawler"
+	"strings"
+	"unicode"
+)
+
+// tocTitle is the heading of the generated table of contents.
+const tocTitle = "Table of Contents"
+
+// writeMarkdownTOC writes a table of contents linking to the "# Title" heading of
+// each page, using the anchors GitHub generates for headings. Every heading in the
+// output takes an anchor in document order, the ones inside page content
+// included, so a title repeating an earlier content heading is numbered as
+// GitHub numbers it.
+func writeMarkdownTOC(buffer *bytes.Buffer, pages []crawler.Page) {
+	anchors := newAnchorSet()
+	anchors.anchor(tocTitle) // The TOC's own heading takes its anchor first
+
+	fmt.Fprintf(buffer, "# %s\n\n", tocTitle)
+	for _, page := range pages {
+		anchor := anchors.anchor(page.Title)
+		for _, heading := range markdownHeadings(page.Content) {
+			anchors.anchor(heading)
+		}
+		if anchor == "" {
+			continue // Nothing to link to without a title
+		}
+		fmt.Fprintf(buffer, "- [%s](#%s)\n", escapeLinkText(page.Title), anchor)
+	}
+	buffer.WriteString(DefaultPageSeparator)
+}
+
+// atxHeading matches a "## Heading" line, capturing its text without any closing #s.
+var atxHeading = regexp.MustCompile(`^ {0,3}#{1,6}(?:[ \t]+(.*?))?(?:[ \t]+#+)?[ \t]*$`)
+
+// setextUnderline matches the "===" or "---" line under a Setext heading.
+var setextUnderline = regexp.MustCompile(`^ {0,3}(?:=+|-+)[ \t]*$`)
+
+// markdownLink matches an inline link, capturing its text.
+var markdownLink = regexp.MustCompile(`!?\[([^\]]*)\]\([^)]*\)`)
+
+// markdownHeadings returns the text of the ATX and Setext headings in markdown
+// content, in order, skipping fenced code blocks. Links are reduced to their text,
+// as they are in the anchors GitHub generates.
+func markdownHeadings(content string) []string {
+	var headings []string
+	var fence string
+	lines := strings.Split(content, "\n")
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if fence != "" {
+			if strings.HasPrefix(trimmed, fence) {
+				fence = ""
+			}
+			continue
+		}
+		if strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~") {
+			fence = trimmed[:3]
+			continue
+		}
+
+		var heading string
+		if match := atxHeading.FindStringSubmatch(line); match != nil {
+			heading = match[1]
+		} else if trimmed != "" && i+1 < len(lines) && setextUnderline.MatchString(lines[i+1]) && !setextUnderline.MatchString(line) {
+			heading = trimmed
+		} else {
+			continue
+		}
+		headings = append(headings, markdownLink.ReplaceAllString(heading, "$1"))
+	}
+	return headings
+}
+
+// anchorSet generates heading anchors, numbering repeats the way GitHub does:
+// the second "Intro" heading is #intro-1, the third #intro-2, and so on.
+type anchorSet struct {
+	seen map[string]int
+}
+
+func newAnchorSet() *anchorSet {
+	return &anchorSet{seen: make(map[string]int)}
+}
+
+// anchor returns the anchor for a heading, or "" if the heading has none.
+func (a *anchorSet) anchor(heading string) string {
+	slug := headingSlug(heading)
+	if slug == "" {
+		return ""
+	}
+	count := a.seen[slug]
+	a.seen[slug] = count + 1
+	if count > 0 {
+		return fmt.Sprintf("%s-%d", slug, count)
+	}
+	return slug
+}
+
+// headingSlug converts a heading to a GitHub-style anchor: lowercased, with
+// punctuation removed and spaces replaced by hyphens.
+func headingSlug(heading string) string {
+	var slug strings.Builder
+	for _, r := range strings.ToLower(strings.TrimSpace(heading)) {
+		switch {
+		case unicode.IsLetter(r) || unicode.IsNumber(r) || r == '-' || r == '_':
+			slug.WriteRune(r)
+		case r == ' ':
+			slug.WriteByte('-')
+		}
+	}
+	return slug.String()
+}
+
+// escapeLinkText escapes the brackets that would end a markdown link's text early.
+func escapeLinkText(text string) string {
+	return strings.NewReplacer(`\`, `\\`, "[", `\[`, "]", `\]`).Replace(strings.TrimSpace(text))
+}
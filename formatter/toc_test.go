@@ -0,0 +1,76 @@
+package formatter
+
+import (
+	"bytes"
+	"reflect"
+	"sitemapExport/crawler"
+	"strings"
+	"testing"
+)
+
+func TestMarkdownHeadings(t *testing.T) {
+	content := strings.Join([]string{
+		"# Top",
+		"Text under it.",
+		"## Closed ##",
+		"Setext one",
+		"==========",
+		"Setext two",
+		"---",
+		"```",
+		"# not a heading",
+		"```",
+		"~~~",
+		"## nor this",
+		"~~~",
+		"#hashtag is not a heading",
+		"### [Linked](https://example.com) heading",
+		"    # Indented code",
+	}, "\n")
+
+	want := []string{"Top", "Closed", "Setext one", "Setext two", "Linked heading"}
+	if got := markdownHeadings(content); !reflect.DeepEqual(got, want) {
+		t.Errorf("markdownHeadings = %q, want %q", got, want)
+	}
+}
+
+func TestMarkdownTOCCountsContentHeadings(t *testing.T) {
+	pages := []crawler.Page{
+		{Title: "Intro", Content: "## Setup\n\nSteps.\n\n## Intro\n\nAgain."},
+		{Title: "Setup", Content: "Text."},
+		{Title: "Intro", Content: "More text."},
+		{Title: "Table of Contents", Content: ""},
+	}
+
+	var buffer bytes.Buffer
+	writeMarkdownTOC(&buffer, pages)
+	toc := buffer.String()
+
+	// Headings in document order: Table of Contents, Intro, Setup, Intro, Setup, Intro, Table of Contents
+	for _, want := range []string{
+		"- [Intro](#intro)\n",
+		"- [Setup](#setup-1)\n",
+		"- [Intro](#intro-2)\n",
+		"- [Table of Contents](#table-of-contents-1)\n",
+	} {
+		if !strings.Contains(toc, want) {
+			t.Errorf("TOC is missing %q:\n%s", want, toc)
+		}
+	}
+}
+
+func TestHeadingSlug(t *testing.T) {
+	tests := map[string]string{
+		"Hello World":        "hello-world",
+		"  What's new?  ":    "whats-new",
+		"C++ & Go_lang":      "c--go_lang",
+		"Déjà vu":            "déjà-vu",
+		"100% sure -- maybe": "100-sure----maybe",
+		"!!!":                "",
+	}
+	for heading, want := range tests {
+		if got := headingSlug(heading); got != want {
+			t.Errorf("headingSlug(%q) = %q, want %q", heading, got, want)
+		}
+	}
+}
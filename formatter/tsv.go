@@ -0,0 +1,43 @@
+package formatter
+
+import (
+	"bytes"
+	"sitemapExport/crawler"
+	"strings"
+)
+
+// tsvHeader names the Page fields written to each tsv row, in order.
+var tsvHeader = []string{"Title", "URL", "Description", "Date", "Content"}
+
+// tsvEscaper escapes the characters that would otherwise break a tsv row, so each
+// page stays on a single line with a fixed number of columns.
+var tsvEscaper = strings.NewReplacer(`\`, `\\`, "\t", `\t`, "\n", `\n`, "\r", `\r`)
+
+// formatTSV formats the pages as tab-separated values with a header row. Content
+// is collapsed with opts.CollapseContent, and truncated to opts.TSVContentLimit
+// characters when a limit is set.
+func formatTSV(pages []crawler.Page, opts Options) (string, error) {
+	var buffer bytes.Buffer
+	buffer.WriteString(strings.Join(tsvHeader, "\t") + "\n")
+	for _, page := range pages {
+		content := strings.TrimSpace(page.Content)
+		if opts.CollapseContent {
+			content = strings.Join(strings.Fields(content), " ")
+		}
+		if opts.TSVContentLimit > 0 {
+			if runes := []rune(content); len(runes) > opts.TSVContentLimit {
+				content = string(runes[:opts.TSVContentLimit])
+			}
+		}
+
+		fields := []string{page.Title, page.URL, page.Description, page.Date, content}
+		for i, field := range fields {
+			if i > 0 {
+				buffer.WriteByte('\t')
+			}
+			buffer.WriteString(tsvEscaper.Replace(field))
+		}
+		buffer.WriteByte('\n')
+	}
+	return buffer.String(), nil
+}
@@ -1,17 +1,26 @@
 package html2text
 
 import (
+	"encoding/csv"
 	"fmt"
 	"strings"
+	"unicode/utf8"
 
 	"github.com/PuerkitoBio/goquery"
 )
 
+// Options controls how elements are laid out as plain text.
+type Options struct {
+	// TableFormat is "pipe" (cells separated by " | "), "grid" (ASCII borders),
+	// or "csv" (comma-separated rows). It defaults to "pipe".
+	TableFormat string
+}
+
 // Convert transforms sanitized HTML content into plain text with custom formatting.
 // It removes all line breaks in the input HTML before processing.
 //
 // Convert returns the plain text content and an error if encountered.
-func Convert(sanitizedHTML string) (string, error) {
+func Convert(sanitizedHTML string, opts Options) (string, error) {
 	// Create a new goquery Document from the cleaned HTML
 	sanitizedDoc, err := goquery.NewDocumentFromReader(strings.NewReader(sanitizedHTML))
 	if err != nil {
@@ -23,7 +32,7 @@ func Convert(sanitizedHTML string) (string, error) {
 
 	// Process contents starting from the <body> tag
 	sanitizedDoc.Find("body").Contents().Each(func(i int, s *goquery.Selection) {
-		handleElement(&contentBuilder, s, 0)
+		handleElement(&contentBuilder, s, 0, opts)
 	})
 
 	return contentBuilder.String(), nil
@@ -32,7 +41,7 @@ func Convert(sanitizedHTML string) (string, error) {
 // handleElement formats different HTML elements into plain text.
 //
 // contentBuilder appends formatted content, and indent tracks the depth for nested elements.
-func handleElement(contentBuilder *strings.Builder, s *goquery.Selection, indent int) {
+func handleElement(contentBuilder *strings.Builder, s *goquery.Selection, indent int, opts Options) {
 	tagName := goquery.NodeName(s)
 
 	// Extract text and clean line breaks for non-preformatted elements
@@ -45,19 +54,19 @@ func handleElement(contentBuilder *strings.Builder, s *goquery.Selection, indent
 	// Format based on the tag name
 	switch tagName {
 	case "h1", "h2", "h3", "h4", "h5", "h6":
-		contentBuilder.WriteString("\n" + text + "\n" + strings.Repeat("-", len(text)) + "\n")
+		handleHeading(contentBuilder, text, int(tagName[1]-'0'))
 	case "p":
 		contentBuilder.WriteString(text + "\n\n")
 	case "ul":
-		handleList(contentBuilder, s, indent, false)
+		handleList(contentBuilder, s, indent, false, opts)
 	case "ol":
-		handleList(contentBuilder, s, indent, true)
+		handleList(contentBuilder, s, indent, true, opts)
 	case "li":
 		// List items are handled in handleList
 	case "br":
 		contentBuilder.WriteString("\n")
 	case "table":
-		handleTable(contentBuilder, s)
+		handleTable(contentBuilder, s, opts.TableFormat)
 		return
 	case "a":
 		handleAnchor(contentBuilder, s)
@@ -72,15 +81,28 @@ func handleElement(contentBuilder *strings.Builder, s *goquery.Selection, indent
 	// Recursively process child elements, skipping <pre>, <table>, <ul>, and <ol>
 	if tagName != "pre" && tagName != "table" && tagName != "ul" && tagName != "ol" {
 		s.Children().Each(func(i int, child *goquery.Selection) {
-			handleElement(contentBuilder, child, indent)
+			handleElement(contentBuilder, child, indent, opts)
 		})
 	}
 }
 
+// handleHeading formats headings so their level stays visible: h1 and h2 are
+// underlined with "=" and "-" (Setext style), h3-h6 are prefixed with "###" to "######".
+func handleHeading(contentBuilder *strings.Builder, text string, level int) {
+	switch level {
+	case 1:
+		contentBuilder.WriteString("\n" + text + "\n" + strings.Repeat("=", utf8.RuneCountInString(text)) + "\n")
+	case 2:
+		contentBuilder.WriteString("\n" + text + "\n" + strings.Repeat("-", utf8.RuneCountInString(text)) + "\n")
+	default:
+		contentBuilder.WriteString("\n" + strings.Repeat("#", level) + " " + text + "\n")
+	}
+}
+
 // handleList processes ordered and unordered lists.
 //
 // If isOrdered is true, it formats an ordered list; otherwise, it formats an unordered list.
-func handleList(contentBuilder *strings.Builder, s *goquery.Selection, indent int, isOrdered bool) {
+func handleList(contentBuilder *strings.Builder, s *goquery.Selection, indent int, isOrdered bool, opts Options) {
 	count := 1
 	indentStr := strings.Repeat("   ", indent)
 
@@ -96,7 +118,7 @@ func handleList(contentBuilder *strings.Builder, s *goquery.Selection, indent in
 			// Process non-list child elements inside <li>
 			child.Contents().Each(func(j int, nestedChild *goquery.Selection) {
 				if goquery.NodeName(nestedChild) != "ul" && goquery.NodeName(nestedChild) != "ol" {
-					handleElement(contentBuilder, nestedChild, indent)
+					handleElement(contentBuilder, nestedChild, indent, opts)
 				}
 			})
 
@@ -106,9 +128,9 @@ func handleList(contentBuilder *strings.Builder, s *goquery.Selection, indent in
 			child.Children().Each(func(j int, nestedChild *goquery.Selection) {
 				switch goquery.NodeName(nestedChild) {
 				case "ul":
-					handleList(contentBuilder, nestedChild, indent+1, false)
+					handleList(contentBuilder, nestedChild, indent+1, false, opts)
 				case "ol":
-					handleList(contentBuilder, nestedChild, indent+1, true)
+					handleList(contentBuilder, nestedChild, indent+1, true, opts)
 				}
 			})
 		}
@@ -120,41 +142,112 @@ func handleList(contentBuilder *strings.Builder, s *goquery.Selection, indent in
 	}
 }
 
-// handleTable formats table elements as plain text with pipe-separated rows.
-func handleTable(contentBuilder *strings.Builder, s *goquery.Selection) {
-	s.Find("tr").Each(func(i int, row *goquery.Selection) {
-		handleTableRow(contentBuilder, row)
-		contentBuilder.WriteString("\n")
-	})
+// handleTable formats table elements as plain text in the given table format.
+func handleTable(contentBuilder *strings.Builder, s *goquery.Selection, format string) {
+	rows, hasHeader := tableRows(s)
+	switch format {
+	case "grid":
+		writeGridTable(contentBuilder, rows, hasHeader)
+	case "csv":
+		writeCSVTable(contentBuilder, rows)
+	default:
+		writePipeTable(contentBuilder, rows)
+	}
 	contentBuilder.WriteString("\n") // Extra line break after the table
 }
 
-// handleTableRow formats a table row.
-func handleTableRow(contentBuilder *strings.Builder, s *goquery.Selection) {
-	first := true
-	s.Children().Each(func(i int, cell *goquery.Selection) {
-		if !first {
-			contentBuilder.WriteString(" | ")
+// tableRows returns the trimmed text of each cell, row by row, and whether the
+// first row is a header row made of <th> cells.
+func tableRows(s *goquery.Selection) ([][]string, bool) {
+	var rows [][]string
+	hasHeader := false
+	s.Find("tr").Each(func(i int, row *goquery.Selection) {
+		var cells []string
+		row.Children().Each(func(j int, cell *goquery.Selection) {
+			cells = append(cells, strings.TrimSpace(cell.Text()))
+		})
+		if i == 0 {
+			hasHeader = row.Children().Length() > 0 && row.Children().Length() == row.Children().Filter("th").Length()
 		}
-		handleTableCell(contentBuilder, cell)
-		first = false
+		rows = append(rows, cells)
 	})
+	return rows, hasHeader
+}
+
+// writePipeTable writes each row with its cells separated by " | ".
+func writePipeTable(contentBuilder *strings.Builder, rows [][]string) {
+	for _, row := range rows {
+		contentBuilder.WriteString(strings.Join(row, " | ") + "\n")
+	}
+}
+
+// writeGridTable writes the rows inside ASCII borders, with the columns padded to
+// the same width and a border under the header row.
+func writeGridTable(contentBuilder *strings.Builder, rows [][]string, hasHeader bool) {
+	var widths []int
+	for _, row := range rows {
+		for i, cell := range row {
+			if i == len(widths) {
+				widths = append(widths, 0)
+			}
+			widths[i] = max(widths[i], utf8.RuneCountInString(cell))
+		}
+	}
+	if len(widths) == 0 {
+		return
+	}
+
+	border := "+"
+	for _, width := range widths {
+		border += strings.Repeat("-", width+2) + "+"
+	}
+
+	contentBuilder.WriteString(border + "\n")
+	for i, row := range rows {
+		contentBuilder.WriteString("|")
+		for j, width := range widths {
+			cell := ""
+			if j < len(row) {
+				cell = row[j]
+			}
+			contentBuilder.WriteString(" " + cell + strings.Repeat(" ", width-utf8.RuneCountInString(cell)) + " |")
+		}
+		contentBuilder.WriteString("\n")
+		if i == 0 && hasHeader && len(rows) > 1 {
+			contentBuilder.WriteString(border + "\n")
+		}
+	}
+	contentBuilder.WriteString(border + "\n")
 }
 
-// handleTableCell formats table cells as plain text.
-func handleTableCell(contentBuilder *strings.Builder, s *goquery.Selection) {
-	contentBuilder.WriteString(strings.TrimSpace(s.Text()))
+// writeCSVTable writes the rows as comma-separated values, quoting cells as needed.
+func writeCSVTable(contentBuilder *strings.Builder, rows [][]string) {
+	writer := csv.NewWriter(contentBuilder)
+	writer.WriteAll(rows) // Writing to a strings.Builder can't fail
 }
 
-// handleAnchor formats <a> tags as "text (URL)".
+// handleAnchor formats <a> tags as "text (URL)". mailto: and tel: links keep their
+// scheme but drop any query, and are written as just "mailto:address" when the text
+// already is the address; javascript: links and in-page anchors are reduced to their text.
 func handleAnchor(contentBuilder *strings.Builder, s *goquery.Selection) {
 	href, exists := s.Attr("href")
 	text := s.Text()
+	lowerHref := strings.ToLower(strings.TrimSpace(href))
 
-	if exists && !strings.HasPrefix(href, "#") {
-		contentBuilder.WriteString(text + " (" + href + ") ")
-	} else {
+	switch {
+	case !exists || strings.HasPrefix(href, "#") || strings.HasPrefix(lowerHref, "javascript:"):
 		contentBuilder.WriteString(text)
+	case strings.HasPrefix(lowerHref, "mailto:") || strings.HasPrefix(lowerHref, "tel:"):
+		scheme, address, _ := strings.Cut(strings.TrimSpace(href), ":")
+		address, _, _ = strings.Cut(address, "?") // Drop ?subject= and the like
+		link := strings.ToLower(scheme) + ":" + address
+		if strings.TrimSpace(text) == address {
+			contentBuilder.WriteString(link + " ")
+		} else {
+			contentBuilder.WriteString(text + " (" + link + ") ")
+		}
+	default:
+		contentBuilder.WriteString(text + " (" + href + ") ")
 	}
 }
 
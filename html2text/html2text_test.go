@@ -0,0 +1,31 @@
+package html2text
+
+import "testing"
+
+func TestConvertLinks(t *testing.T) {
+	tests := []struct {
+		name, html, want string
+	}{
+		{"web link", `<a href="https://example.com/">Example</a>`, "Example (https://example.com/) "},
+		{"mailto", `<a href="mailto:team@example.com">Email us</a>`, "Email us (mailto:team@example.com) "},
+		{"mailto with query", `<a href="mailto:team@example.com?subject=Hello">Email us</a>`, "Email us (mailto:team@example.com) "},
+		{"mailto showing the address", `<a href="mailto:team@example.com">team@example.com</a>`, "mailto:team@example.com "},
+		{"mailto in capitals", `<a href="MAILTO:team@example.com">Email us</a>`, "Email us (mailto:team@example.com) "},
+		{"tel", `<a href="tel:+1-555-0100">Call us</a>`, "Call us (tel:+1-555-0100) "},
+		{"tel showing the number", `<a href="tel:+1-555-0100">+1-555-0100</a>`, "tel:+1-555-0100 "},
+		{"javascript", `<a href="javascript:void(0)">Open menu</a>`, "Open menu"},
+		{"javascript with spaces", `<a href=" JavaScript:alert(1)">Open menu</a>`, "Open menu"},
+		{"anchor", `<a href="#section">Jump</a>`, "Jump"},
+		{"no href", `<a>Plain</a>`, "Plain"},
+		{"in a list", `<ul><li><a href="tel:123">Call</a></li></ul>`, "- Call (tel:123) \n\n"},
+	}
+	for _, tt := range tests {
+		got, err := Convert(tt.html, Options{})
+		if err != nil {
+			t.Fatalf("%s: Convert: %v", tt.name, err)
+		}
+		if got != tt.want {
+			t.Errorf("%s: Convert(%s) = %q, want %q", tt.name, tt.html, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,89 @@
+package writer
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"regexp"
+	"sitemapExport/crawler"
+	"strings"
+
+	"github.com/jung-kurt/gofpdf"
+)
+
+// imagePlaceholder matches a line holding just an image reference, as txt content
+// writes them ("Image: alt (src)") and as md content does ("![alt](src)").
+var imagePlaceholder = regexp.MustCompile(`^(?:Image:.*\((https?://\S+)\)|!\[[^\]]*\]\((https?://[^\s)]+)(?: "[^"]*")?\))$`)
+
+// gofpdfImageTypes maps the image media types gofpdf can embed to its type names.
+var gofpdfImageTypes = map[string]string{
+	"image/jpeg": "JPG",
+	"image/png":  "PNG",
+	"image/gif":  "GIF",
+}
+
+// addTextWithImages adds content line by line, drawing the image of each image
+// placeholder line in its place. Placeholders whose image can't be embedded stay
+// as text.
+func (w *PDFWriter) addTextWithImages(content string) {
+	for _, line := range strings.Split(strings.TrimRight(content, "\n"), "\n") {
+		if match := imagePlaceholder.FindStringSubmatch(strings.TrimSpace(line)); match != nil {
+			if w.addImage(match[1] + match[2]) {
+				continue
+			}
+		}
+
+		lines := w.wrapText(line)
+		if len(lines) == 0 {
+			lines = []string{""} // Keep blank lines
+		}
+		w.addLines(lines)
+	}
+}
+
+// addImage draws the image at src at the current position, scaled down to fit the
+// page, and reports whether it could. Each image is downloaded once per PDF.
+func (w *PDFWriter) addImage(src string) bool {
+	name, seen := w.images[src]
+	if !seen {
+		name = w.registerImage(src)
+		w.images[src] = name
+	}
+	if name == "" {
+		return false
+	}
+
+	width, height := w.pdf.GetImageInfo(name).Extent()
+	if width > w.width {
+		width, height = w.width, height*w.width/width
+	}
+	if height > w.maxImageHeight {
+		width, height = width*w.maxImageHeight/height, w.maxImageHeight
+	}
+	w.pdf.ImageOptions(name, -1, 0, width, height, true, gofpdf.ImageOptions{}, 0, "")
+	return true
+}
+
+// registerImage downloads the image at src and adds it to the PDF, returning the
+// name it is registered under, or "" if it can't be embedded.
+func (w *PDFWriter) registerImage(src string) string {
+	if w.pdf.Err() {
+		return "" // Don't clear an earlier error below
+	}
+
+	data, mediaType, err := crawler.FetchImage(context.Background(), src, w.maxImageBytes)
+	if err == nil && gofpdfImageTypes[mediaType] == "" {
+		err = fmt.Errorf("%s images can't be embedded in a PDF", mediaType)
+	}
+	if err == nil {
+		w.pdf.RegisterImageOptionsReader(src, gofpdf.ImageOptions{ImageType: gofpdfImageTypes[mediaType]}, bytes.NewReader(data))
+		if err = w.pdf.Error(); err != nil {
+			w.pdf.ClearError() // gofpdf stops drawing anything after an error
+		}
+	}
+	if err != nil {
+		fmt.Printf("Skipping image %s: %v\n", src, err)
+		return ""
+	}
+	return src
+}
@@ -0,0 +1,85 @@
+package writer
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sitemapExport/crawler"
+)
+
+// WrittenFile is an output file along with the URL its content came from.
+type WrittenFile struct {
+	Path string
+	URL  string
+}
+
+// ManifestEntry records a written file's checksum so an archive can be verified later.
+type ManifestEntry struct {
+	File   string `json:"File"` // Relative to the manifest
+	SHA256 string `json:"SHA256"`
+	Bytes  int64  `json:"Bytes"`
+	URL    string `json:"URL,omitempty"` // The page, or for combined output the feed, it was exported from
+}
+
+// manifest is the on-disk format of a manifest file.
+type manifest struct {
+	Files []ManifestEntry `json:"Files"`
+}
+
+// WriteManifest hashes each written file and lists them in a JSON manifest at
+// manifestPath, with file paths relative to the manifest's directory.
+func WriteManifest(manifestPath string, files []WrittenFile) error {
+	base := filepath.Dir(manifestPath)
+	entries := make([]ManifestEntry, 0, len(files))
+	for _, file := range files {
+		sum, size, err := hashFile(file.Path)
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(base, file.Path)
+		if err != nil {
+			rel = file.Path
+		}
+		entries = append(entries, ManifestEntry{
+			File:   filepath.ToSlash(rel),
+			SHA256: sum,
+			Bytes:  size,
+			URL:    file.URL,
+		})
+	}
+
+	data, err := json.MarshalIndent(manifest{Files: entries}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal manifest: %w", err)
+	}
+	return writeTextFile(manifestPath, string(data)+"\n")
+}
+
+// WriteSummary writes a crawl summary as indented JSON to path.
+func WriteSummary(path string, summary crawler.CrawlSummary) error {
+	data, err := json.MarshalIndent(summary, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal crawl summary: %w", err)
+	}
+	return writeTextFile(path, string(data)+"\n")
+}
+
+// hashFile returns the hex SHA-256 and size of a file.
+func hashFile(path string) (string, int64, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", 0, fmt.Errorf("error opening file %s: %w", path, err)
+	}
+	defer file.Close()
+
+	hash := sha256.New()
+	size, err := io.Copy(hash, file)
+	if err != nil {
+		return "", 0, fmt.Errorf("error reading file %s: %w", path, err)
+	}
+	return hex.EncodeToString(hash.Sum(nil)), size, nil
+}
@@ -0,0 +1,133 @@
+package writer
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"path"
+	"sitemapExport/crawler"
+	"strings"
+
+	"github.com/kennygrant/sanitize"
+)
+
+// maxSlugLength caps slugs so filenames stay well within filesystem limits.
+const maxSlugLength = 80
+
+// SplitManifestFile is the name of the manifest written inside a split output
+// directory. Pages are never given its name, so they can't overwrite it.
+const SplitManifestFile = "manifest.json"
+
+// reservedNames are the names Slugger disambiguates even when only one page has them.
+var reservedNames = map[string]bool{
+	strings.TrimSuffix(SplitManifestFile, path.Ext(SplitManifestFile)): true,
+}
+
+// Slugger produces unique, filesystem-safe file names for a set of pages, either
+// slugs of their titles or paths mirroring their URLs. A name that would be shared
+// by several pages, or that is reserved for another file, gets a short hash of
+// the page URL appended on every one of them, so each page's name depends only
+// on the set of pages, never on the order they are named in.
+type Slugger struct {
+	preservePath bool
+	shared       map[string]int // Name -> number of pages it would be given to
+	used         map[string]bool
+}
+
+// NewSlugger creates a slugger for pages, naming them by title or, with
+// preservePath, by URL path.
+func NewSlugger(pages []crawler.Page, preservePath bool) *Slugger {
+	s := &Slugger{preservePath: preservePath, shared: make(map[string]int), used: make(map[string]bool)}
+	for _, page := range pages {
+		s.shared[s.base(page)]++
+	}
+	return s
+}
+
+// Name returns the file name or relative path (without extension) for one of the
+// slugger's pages.
+func (s *Slugger) Name(page crawler.Page) string {
+	base := s.base(page)
+	name := base
+	if s.shared[base] > 1 || reservedNames[base] {
+		name = fmt.Sprintf("%s-%s", base, urlHash(page.URL))
+	}
+	// The same URL listed twice would hash to the same name; number those
+	for i := 1; s.used[name]; i++ {
+		name = fmt.Sprintf("%s-%s-%d", base, urlHash(page.URL), i)
+	}
+
+	s.used[name] = true
+	return name
+}
+
+// base returns the name for the page before any disambiguation.
+func (s *Slugger) base(page crawler.Page) string {
+	if s.preservePath {
+		return pathName(page.URL)
+	}
+	return slugName(page)
+}
+
+// slugName returns a name for the page derived from its title or, failing that,
+// its URL path.
+func slugName(page crawler.Page) string {
+	if slug := slugify(page.Title); slug != "" {
+		return slug
+	}
+	return slugFromURL(page.URL)
+}
+
+// pathName returns a relative path for the page that mirrors its URL path, e.g.
+// "blog/2024/post" for /blog/2024/post.html, with "index" for paths ending in "/".
+// Each directory and the file name are made filesystem-safe.
+func pathName(pageURL string) string {
+	parsedURL, err := url.Parse(pageURL)
+	if err != nil {
+		return "index"
+	}
+	var segments []string
+	for _, segment := range strings.Split(parsedURL.Path, "/") {
+		if slug := slugify(strings.TrimSuffix(segment, path.Ext(segment))); slug != "" {
+			segments = append(segments, slug)
+		}
+	}
+	if len(segments) > 0 && !strings.HasSuffix(parsedURL.Path, "/") {
+		return path.Join(segments...)
+	}
+	return path.Join(append(segments, "index")...)
+}
+
+// urlHash returns a short, stable hash of a URL for disambiguating slugs.
+func urlHash(pageURL string) string {
+	sum := sha1.Sum([]byte(pageURL))
+	return hex.EncodeToString(sum[:4])
+}
+
+// slugify lowercases text and strips it down to a length-capped, filesystem-safe name.
+func slugify(text string) string {
+	slug := sanitize.BaseName(strings.ToLower(text))
+	slug = strings.Trim(slug, "-.")
+	if len(slug) > maxSlugLength {
+		slug = strings.TrimRight(slug[:maxSlugLength], "-.")
+	}
+	return slug
+}
+
+// slugFromURL derives a slug from the URL path, using "index" for the site root.
+func slugFromURL(pageURL string) string {
+	parsedURL, err := url.Parse(pageURL)
+	if err != nil {
+		return "page"
+	}
+
+	path := strings.Trim(parsedURL.Path, "/")
+	if path == "" {
+		return "index"
+	}
+	if slug := slugify(path); slug != "" {
+		return slug
+	}
+	return "page"
+}
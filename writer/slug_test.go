@@ -0,0 +1,137 @@
+package writer
+
+import (
+	"sitemapExport/crawler"
+	"strings"
+	"testing"
+)
+
+func TestSlug(t *testing.T) {
+	tests := []struct {
+		title, url, want string
+	}{
+		{"Hello World", "https://example.com/hello", "hello-world"},
+		{"Hello/World", "https://example.com/hello", "hello-world"},
+		{`a\b:c*?`, "https://example.com/a", "ab-c"},
+		{"  ..Dots..  ", "https://example.com/dots", "dots"},
+		{"Café déjà vu", "https://example.com/cafe", "cafe-deja-vu"},
+		{"München Straße", "https://example.com/muenchen", "muenchen-strasse"},
+		// Titles with nothing usable fall back to the URL path
+		{"日本語", "https://example.com/docs/intro.html", "docs-intro-html"},
+		{"", "https://example.com/about/team/", "about-team"},
+		{"", "https://example.com/", "index"},
+		{"", "https://example.com/日本語", "page"},
+	}
+	for _, tt := range tests {
+		page := crawler.Page{Title: tt.title, URL: tt.url}
+		if got := NewSlugger([]crawler.Page{page}, false).Name(page); got != tt.want {
+			t.Errorf("Name(%q, %q) = %q, want %q", tt.title, tt.url, got, tt.want)
+		}
+	}
+}
+
+func TestSlugLength(t *testing.T) {
+	page := crawler.Page{Title: strings.Repeat("word ", 40), URL: "https://example.com/long"}
+	slug := NewSlugger([]crawler.Page{page}, false).Name(page)
+	if len(slug) > maxSlugLength || strings.HasSuffix(slug, "-") {
+		t.Errorf("Name = %q (%d bytes), want at most %d bytes without a trailing hyphen", slug, len(slug), maxSlugLength)
+	}
+}
+
+func TestSlugDuplicates(t *testing.T) {
+	pages := []crawler.Page{
+		{Title: "Same", URL: "https://example.com/a"},
+		{Title: "Same", URL: "https://example.com/b"},
+		{Title: "same", URL: "https://example.com/c"},
+		{Title: "Other", URL: "https://example.com/d"},
+	}
+	s := NewSlugger(pages, false)
+	seen := make(map[string]bool)
+	for _, page := range pages[:3] {
+		slug := s.Name(page)
+		if seen[slug] {
+			t.Errorf("Name(%q) = %q, already used", page.URL, slug)
+		}
+		// Every page sharing the title is disambiguated, the first one included
+		if want := "same-" + urlHash(page.URL); slug != want {
+			t.Errorf("Name(%q) = %q, want %q", page.URL, slug, want)
+		}
+		seen[slug] = true
+	}
+	if slug := s.Name(pages[3]); slug != "other" {
+		t.Errorf("Name of a page with a unique title = %q, want %q", slug, "other")
+	}
+}
+
+func TestSlugRepeatedURL(t *testing.T) {
+	page := crawler.Page{Title: "Same", URL: "https://example.com/a"}
+	s := NewSlugger([]crawler.Page{page, page}, false)
+	if first, second := s.Name(page), s.Name(page); first == second {
+		t.Errorf("repeated URL got %q twice", first)
+	}
+}
+
+func TestSlugIndependentOfOrder(t *testing.T) {
+	pages := []crawler.Page{
+		{Title: "Same", URL: "https://example.com/a"},
+		{Title: "Other", URL: "https://example.com/b"},
+		{Title: "Same", URL: "https://example.com/c"},
+		{Title: "", URL: "https://example.com/docs/"},
+		{Title: "Other", URL: "https://example.com/a.html"}, // Shares a title with b and a path with a
+	}
+	reversed := make([]crawler.Page, len(pages))
+	for i, page := range pages {
+		reversed[len(pages)-1-i] = page
+	}
+
+	for _, preservePath := range []bool{false, true} {
+		names := make(map[string]string)
+		s := NewSlugger(pages, preservePath)
+		for _, page := range pages {
+			names[page.URL] = s.Name(page)
+		}
+
+		s = NewSlugger(reversed, preservePath)
+		for _, page := range reversed {
+			if name := s.Name(page); name != names[page.URL] {
+				t.Errorf("preservePath=%t: %s is named %q in one order and %q in the other", preservePath, page.URL, names[page.URL], name)
+			}
+		}
+	}
+}
+
+func TestSlugPreservePath(t *testing.T) {
+	tests := []struct {
+		url, want string
+	}{
+		{"https://example.com/blog/2024/Post Name.html", "blog/2024/post-name"},
+		{"https://example.com/docs/", "docs/index"},
+		{"https://example.com", "index"},
+		{"https://example.com/a/../b", "a/b"},
+	}
+	for _, tt := range tests {
+		page := crawler.Page{URL: tt.url}
+		if got := NewSlugger([]crawler.Page{page}, true).Name(page); got != tt.want {
+			t.Errorf("Name(%q) = %q, want %q", tt.url, got, tt.want)
+		}
+	}
+}
+
+func TestSlugAvoidsManifest(t *testing.T) {
+	pages := []crawler.Page{
+		{Title: "Manifest", URL: "https://example.com/about/manifest"},
+		{Title: "Other", URL: "https://example.com/manifest.html"},
+		{Title: "Nested", URL: "https://example.com/docs/manifest"},
+	}
+	if name := NewSlugger(pages, false).Name(pages[0]); name == "manifest" {
+		t.Errorf("page titled Manifest is named %q, the manifest's own name", name)
+	}
+
+	s := NewSlugger(pages, true)
+	if name := s.Name(pages[1]); name == "manifest" {
+		t.Errorf("page at /manifest.html is named %q, the manifest's own name", name)
+	}
+	if name := s.Name(pages[2]); name != "docs/manifest" {
+		t.Errorf("page at /docs/manifest is named %q, want docs/manifest", name)
+	}
+}
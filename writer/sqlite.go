@@ -0,0 +1,114 @@
+package writer
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sitemapExport/crawler"
+
+	_ "modernc.org/sqlite" // Pure-Go SQLite driver, registered as "sqlite"
+)
+
+// sqliteSchema creates the pages table. Scalar Page fields get their own column;
+// lists and objects are stored as JSON text, queryable with SQLite's json functions.
+const sqliteSchema = `CREATE TABLE IF NOT EXISTS pages (
+	id          INTEGER PRIMARY KEY AUTOINCREMENT,
+	title       TEXT NOT NULL,
+	raw_title   TEXT,
+	url         TEXT NOT NULL,
+	fetched_url TEXT,
+	description TEXT,
+	tags        TEXT,
+	language    TEXT,
+	date        TEXT,
+	image       TEXT,
+	enclosure   TEXT,
+	source      TEXT,
+	alternates  TEXT,
+	fields      TEXT,
+	links       TEXT,
+	http        TEXT,
+	comments    TEXT,
+	content     TEXT NOT NULL,
+	raw_html    TEXT,
+	status      TEXT,
+	error       TEXT
+)`
+
+// sqliteInsert inserts one page, with the columns in the order of sqliteSchema.
+const sqliteInsert = `INSERT INTO pages (
+	title, raw_title, url, fetched_url, description, tags, language, date, image, enclosure,
+	source, alternates, fields, links, http, comments, content, raw_html, status, error
+) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+
+// WritePagesSQLite writes pages into the pages table of filename.sqlite in a single
+// transaction. The file is replaced unless appendMode is set, in which case the
+// pages are added to its existing table.
+func WritePagesSQLite(filename string, pages []crawler.Page, appendMode bool) error {
+	filepath := filename + ".sqlite"
+	if !appendMode {
+		if err := os.Remove(filepath); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("error replacing file %s: %w", filepath, err)
+		}
+	}
+
+	db, err := sql.Open("sqlite", filepath)
+	if err != nil {
+		return fmt.Errorf("error opening database %s: %w", filepath, err)
+	}
+	defer db.Close()
+
+	if _, err := db.Exec(sqliteSchema); err != nil {
+		return fmt.Errorf("error creating pages table in %s: %w", filepath, err)
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("error starting transaction in %s: %w", filepath, err)
+	}
+	defer tx.Rollback() // No-op once committed
+
+	stmt, err := tx.Prepare(sqliteInsert)
+	if err != nil {
+		return fmt.Errorf("error preparing insert in %s: %w", filepath, err)
+	}
+	defer stmt.Close()
+
+	for _, page := range pages {
+		_, err := stmt.Exec(
+			page.Title, nullString(page.RawTitle), page.URL, nullString(page.FetchedURL),
+			nullString(page.Description), jsonColumn(page.Tags), nullString(page.Language),
+			nullString(page.Date), nullString(page.Image), nullString(page.Enclosure),
+			nullString(page.Source), jsonColumn(page.Alternates), jsonColumn(page.Fields), jsonColumn(page.Links),
+			jsonColumn(page.HTTP), jsonColumn(page.Comments), page.Content,
+			nullString(page.RawHTML), nullString(page.Status), nullString(page.Error),
+		)
+		if err != nil {
+			return fmt.Errorf("error inserting %s into %s: %w", page.URL, filepath, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("error committing pages to %s: %w", filepath, err)
+	}
+	return nil
+}
+
+// nullString stores empty strings as NULL, so missing values can be found with IS NULL.
+func nullString(value string) sql.NullString {
+	return sql.NullString{String: value, Valid: value != ""}
+}
+
+// jsonColumn encodes a list or object as JSON text, storing empty values as NULL.
+func jsonColumn(value any) sql.NullString {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return sql.NullString{}
+	}
+	switch string(data) {
+	case "null", "[]", "{}":
+		return sql.NullString{}
+	}
+	return sql.NullString{String: string(data), Valid: true}
+}
@@ -0,0 +1,174 @@
+package writer
+
+import (
+	"fmt"
+	"os"
+	"sitemapExport/crawler"
+	"sitemapExport/formatter"
+)
+
+// syncEvery is how many pages are written between syncs of the stream to disk.
+const syncEvery = 50
+
+// PageStream writes pages to an output file one at a time as they are crawled.
+type PageStream interface {
+	Write(page crawler.Page) error // Write adds one page to the output
+	Count() int                    // Count returns the number of pages written so far
+	Close() error                  // Close finishes the output and closes the file
+}
+
+// JSONLStream writes pages to a jsonl file one line at a time as they are crawled.
+// Each line goes straight to the file without buffering, so the output can be
+// followed with `tail -f` while the crawl is running.
+type JSONLStream struct {
+	file  *os.File
+	opts  formatter.Options
+	count int
+}
+
+// NewJSONLStream creates (or, when appending, opens) filename.jsonl for streaming,
+// formatting each line with opts.
+func NewJSONLStream(filename string, appendMode bool, opts formatter.Options) (*JSONLStream, error) {
+	filepath := filename + ".jsonl"
+	flags := os.O_CREATE | os.O_WRONLY | os.O_TRUNC
+	if appendMode {
+		flags = os.O_CREATE | os.O_WRONLY | os.O_APPEND
+	}
+
+	file, err := os.OpenFile(filepath, flags, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("error opening file %s: %w", filepath, err)
+	}
+	return &JSONLStream{file: file, opts: opts}, nil
+}
+
+// Write appends one page to the stream as a single JSON line.
+func (s *JSONLStream) Write(page crawler.Page) error {
+	line, err := formatter.FormatPages([]crawler.Page{page}, "jsonl", s.opts)
+	if err != nil {
+		return err
+	}
+	if _, err := s.file.WriteString(line); err != nil {
+		return fmt.Errorf("error writing to file %s: %w", s.file.Name(), err)
+	}
+
+	s.count++
+	if s.count%syncEvery == 0 {
+		return s.file.Sync()
+	}
+	return nil
+}
+
+// Count returns the number of pages written so far.
+func (s *JSONLStream) Count() int {
+	return s.count
+}
+
+// Close syncs any remaining output to disk and closes the file.
+func (s *JSONLStream) Close() error {
+	return syncAndClose(s.file)
+}
+
+// JSONArrayStream writes pages to a json file as they are crawled, opening the
+// array up front and closing it at the end, so the output is a valid JSON array
+// (formatted as the regular json output) without holding every page in memory.
+// The file is only valid JSON once the stream is closed.
+type JSONArrayStream struct {
+	file  *os.File
+	opts  formatter.Options
+	count int
+}
+
+// NewJSONArrayStream creates filename.json and opens the array in it.
+func NewJSONArrayStream(filename string, opts formatter.Options) (*JSONArrayStream, error) {
+	filepath := filename + ".json"
+	file, err := os.Create(filepath)
+	if err != nil {
+		return nil, fmt.Errorf("error creating file %s: %w", filepath, err)
+	}
+	if _, err := file.WriteString("["); err != nil {
+		file.Close()
+		return nil, fmt.Errorf("error writing to file %s: %w", filepath, err)
+	}
+	return &JSONArrayStream{file: file, opts: opts}, nil
+}
+
+// Write adds one page to the array.
+func (s *JSONArrayStream) Write(page crawler.Page) error {
+	element, err := formatter.FormatJSONArrayElement(page, s.opts)
+	if err != nil {
+		return err
+	}
+	separator := ",\n"
+	if s.count == 0 {
+		separator = "\n"
+	}
+	if _, err := s.file.WriteString(separator + element); err != nil {
+		return fmt.Errorf("error writing to file %s: %w", s.file.Name(), err)
+	}
+
+	s.count++
+	if s.count%syncEvery == 0 {
+		return s.file.Sync()
+	}
+	return nil
+}
+
+// Count returns the number of pages written so far.
+func (s *JSONArrayStream) Count() int {
+	return s.count
+}
+
+// Close closes the array, syncs the output to disk, and closes the file.
+func (s *JSONArrayStream) Close() error {
+	closing := "\n]"
+	if s.count == 0 {
+		closing = "]"
+	}
+	if _, err := s.file.WriteString(closing); err != nil {
+		s.file.Close()
+		return fmt.Errorf("error writing to file %s: %w", s.file.Name(), err)
+	}
+	return syncAndClose(s.file)
+}
+
+// PDFStream renders pages into a PDF as they are crawled, so neither the pages nor
+// their formatted text are kept until the crawl ends. The PDF document is built in
+// memory and only written to its file when the stream is closed.
+type PDFStream struct {
+	pdf   *PDFWriter
+	opts  formatter.Options
+	count int
+}
+
+// NewPDFStream creates a stream that saves filename.pdf, laid out with pdfOpts,
+// when closed, formatting each page's text with opts.
+func NewPDFStream(filename string, pdfOpts PDFOptions, opts formatter.Options) *PDFStream {
+	return &PDFStream{pdf: NewPDFWriter(filename+".pdf", pdfOpts), opts: opts}
+}
+
+// Write renders one page into the PDF.
+func (s *PDFStream) Write(page crawler.Page) error {
+	s.pdf.AddText(formatter.FormatPage(page, s.opts))
+	s.count++
+	return nil
+}
+
+// Count returns the number of pages written so far.
+func (s *PDFStream) Count() int {
+	return s.count
+}
+
+// Close writes the PDF to its file.
+func (s *PDFStream) Close() error {
+	return s.pdf.Close()
+}
+
+// syncAndClose syncs a stream's file to disk and closes it.
+func syncAndClose(file *os.File) error {
+	if err := file.Sync(); err != nil {
+		file.Close()
+		return fmt.Errorf("error syncing file %s: %w", file.Name(), err)
+	}
+	return file.Close()
+}
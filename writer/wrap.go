@@ -0,0 +1,107 @@
+package writer
+
+import (
+	"strings"
+	"unicode"
+
+	"github.com/rivo/uniseg"
+)
+
+// wrapText splits content into lines that fit within the writer's width. Lines are
+// broken only where the Unicode line breaking rules allow (between words, or
+// between CJK characters), and never inside a grapheme cluster, so accented and
+// combined characters stay whole. A word wider than the page is broken between
+// its grapheme clusters. Trailing newlines are dropped and blank lines are kept.
+func (w *PDFWriter) wrapText(content string) []string {
+	content = strings.TrimRight(content, "\n")
+	maxWidth := w.width - 2*w.pdf.GetCellMargin()
+
+	var lines []string
+	line := ""
+	for len(content) > 0 {
+		var segment string
+		var mustBreak bool
+		segment, content, mustBreak = nextLineSegment(content)
+		if mustBreak {
+			segment = strings.TrimRightFunc(segment, isLineBreak)
+		}
+
+		// Move the segment to a new line when it doesn't fit after the current one
+		if line != "" && w.textWidth(line+segment) > maxWidth {
+			lines = append(lines, trimTrailingSpace(line))
+			line = ""
+		}
+
+		// A segment wider than a whole line is broken between grapheme clusters
+		for line == "" && w.textWidth(segment) > maxWidth {
+			head, tail := w.fitClusters(segment, maxWidth)
+			lines = append(lines, head)
+			segment = tail
+		}
+
+		line += segment
+		if mustBreak {
+			lines = append(lines, trimTrailingSpace(line))
+			line = ""
+		}
+	}
+	if line != "" {
+		lines = append(lines, trimTrailingSpace(line))
+	}
+	return lines
+}
+
+// nextLineSegment returns the text up to the next line break opportunity, the
+// rest of the text, and whether the break there is mandatory (a newline or the
+// end of the text).
+func nextLineSegment(text string) (segment, rest string, mustBreak bool) {
+	state := -1
+	rest = text
+	for len(rest) > 0 {
+		var boundaries int
+		_, rest, boundaries, state = uniseg.StepString(rest, state)
+		switch boundaries & uniseg.MaskLine {
+		case uniseg.LineMustBreak:
+			return text[:len(text)-len(rest)], rest, true
+		case uniseg.LineCanBreak:
+			return text[:len(text)-len(rest)], rest, false
+		}
+	}
+	return text, "", true
+}
+
+// fitClusters splits text after the last grapheme cluster that keeps it within
+// maxWidth, always keeping at least one cluster so wrapping makes progress.
+func (w *PDFWriter) fitClusters(text string, maxWidth float64) (head, tail string) {
+	end := 0
+	graphemes := uniseg.NewGraphemes(text)
+	for graphemes.Next() {
+		_, to := graphemes.Positions()
+		if end > 0 && w.textWidth(text[:to]) > maxWidth {
+			break
+		}
+		end = to
+	}
+	return text[:end], text[end:]
+}
+
+// textWidth returns the printed width of text in the current font, ignoring
+// trailing spaces, which are dropped when a line is broken.
+func (w *PDFWriter) textWidth(text string) float64 {
+	return w.pdf.GetStringWidth(trimTrailingSpace(text))
+}
+
+// trimTrailingSpace removes the whitespace left at the end of a wrapped line.
+func trimTrailingSpace(line string) string {
+	return strings.TrimRightFunc(line, unicode.IsSpace)
+}
+
+// isLineBreak reports whether r ends a line: a newline or one of the other
+// mandatory break characters.
+func isLineBreak(r rune) bool {
+	switch r {
+	case '\n', '\r', '\v', '\f', '\u0085', '\u2028', '\u2029':
+		return true
+	}
+	return false
+}
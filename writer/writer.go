@@ -3,17 +3,66 @@ package writer
 import (
 	"fmt"
 	"os"
+	"path/filepath"
+	"sitemapExport/crawler"
+	"sitemapExport/formatter"
 	"strings"
 
 	"github.com/jung-kurt/gofpdf"
 )
 
+// WritePagesPDF renders pages that are already collected into a PDF one at a time,
+// so the whole export is never formatted into a single string.
+func WritePagesPDF(filename string, pages []crawler.Page, opts PDFOptions, textOpts formatter.Options) error {
+	stream := NewPDFStream(filename, opts, textOpts)
+	for _, page := range pages {
+		stream.Write(page)
+	}
+	return stream.Close()
+}
+
+// WritePagesSplit writes each page to its own file inside dir, named by a slug of its title
+// or, with preservePath, at a path mirroring its URL path. PDF files use pdfOpts for their
+// layout and text-based files use textOpts. It returns the files written, in page order.
+func WritePagesSplit(dir string, pages []crawler.Page, format string, preservePath bool, pdfOpts PDFOptions, textOpts formatter.Options) ([]WrittenFile, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("error creating directory %s: %w", dir, err)
+	}
+
+	slugger := NewSlugger(pages, preservePath)
+	files := make([]WrittenFile, 0, len(pages))
+	for _, page := range pages {
+		name := filepath.Join(dir, filepath.FromSlash(slugger.Name(page)))
+		if preservePath {
+			if err := os.MkdirAll(filepath.Dir(name), 0o755); err != nil {
+				return nil, fmt.Errorf("error creating directory %s: %w", filepath.Dir(name), err)
+			}
+		}
+		if format == "pdf" {
+			if err := WritePagesPDF(name, []crawler.Page{page}, pdfOpts, textOpts); err != nil {
+				return nil, err
+			}
+		} else {
+			content, err := formatter.FormatPages([]crawler.Page{page}, format, textOpts)
+			if err != nil {
+				return nil, err
+			}
+			if err := WriteToFile(name, content, format); err != nil {
+				return nil, err
+			}
+		}
+		files = append(files, WrittenFile{Path: name + "." + format, URL: page.URL})
+	}
+
+	return files, nil
+}
+
 // WriteToFile writes formatted content to a file based on the selected format.
 func WriteToFile(filename, content, format string) error {
 	filepath := filename + "." + format
 
 	switch format {
-	case "txt", "md", "json", "jsonl":
+	case "txt", "md", "json", "jsonl", "tsv":
 		return writeTextFile(filepath, content)
 	case "pdf":
 		return writePDF(filepath, content)
@@ -22,7 +71,44 @@ func WriteToFile(filename, content, format string) error {
 	}
 }
 
-// writeTextFile writes content as plain text, markdown, or JSON file.
+// AppendToFile appends formatted content to an existing file (creating it if needed).
+// Only line-oriented formats can be appended to; JSON arrays and PDFs cannot. The
+// header row of tsv content is dropped when the file already has one.
+func AppendToFile(filename, content, format string) error {
+	if !CanAppend(format) {
+		return fmt.Errorf("cannot append to %s output, only txt, md, jsonl, tsv, and sqlite support --append", format)
+	}
+
+	filepath := filename + "." + format
+	if format == "tsv" {
+		if info, err := os.Stat(filepath); err == nil && info.Size() > 0 {
+			_, content, _ = strings.Cut(content, "\n")
+		}
+	}
+	file, err := os.OpenFile(filepath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return fmt.Errorf("error opening file %s: %w", filepath, err)
+	}
+	defer file.Close()
+
+	if _, err = file.WriteString(content); err != nil {
+		return fmt.Errorf("error writing to file %s: %w", filepath, err)
+	}
+
+	return nil
+}
+
+// CanAppend reports whether output of the given format can be appended to an existing file.
+func CanAppend(format string) bool {
+	switch format {
+	case "txt", "md", "jsonl", "tsv", "sqlite":
+		return true
+	default:
+		return false
+	}
+}
+
+// writeTextFile writes content as a plain text, markdown, JSON, or tsv file.
 func writeTextFile(filepath, content string) error {
 	file, err := os.Create(filepath)
 	if err != nil {
@@ -39,31 +125,106 @@ func writeTextFile(filepath, content string) error {
 
 // writePDF generates a PDF file with the provided content.
 func writePDF(filepath, content string) error {
-	pdf := gofpdf.New("P", "mm", "A4", "")
+	pdf := NewPDFWriter(filepath, DefaultPDFOptions)
+	pdf.AddText(content)
+	return pdf.Close()
+}
+
+// PDFOptions controls the page layout of PDF output.
+type PDFOptions struct {
+	PageSize string  // Page size name understood by gofpdf, e.g. "A4" or "Letter"
+	FontSize float64 // Font size in points
+	Margin   float64 // Page margin on every side in mm
+	FontFile string  // Optional TrueType font file; when set, text keeps its non-ASCII characters
+
+	// IncludeImages downloads the images referenced by "Image: alt (src)" and
+	// "![alt](src)" lines and draws them in place of the line, keeping the line
+	// as text when an image can't be fetched or isn't a JPEG, PNG, or GIF.
+	IncludeImages bool
+	MaxImageBytes int64 // Images larger than this are kept as text with IncludeImages; 0 for no limit
+}
+
+// DefaultPDFOptions is the A4, 12pt, 10mm-margin layout used when nothing else is configured.
+var DefaultPDFOptions = PDFOptions{PageSize: "A4", FontSize: 12, Margin: 10}
+
+// PDFWriter builds a PDF incrementally so callers can add content one page at a
+// time instead of rendering the whole export into a single string first.
+type PDFWriter struct {
+	pdf        *gofpdf.Fpdf
+	filepath   string
+	width      float64 // Usable content width in mm
+	lineHeight float64 // Height of each line in mm
+	utf8       bool    // Whether a UTF-8 font is loaded, so text needn't be reduced to ASCII
+
+	// Image embedding, when IncludeImages is set
+	images         map[string]string // Image URL to its registered name, or "" if it couldn't be embedded
+	maxImageHeight float64           // Usable content height in mm
+	maxImageBytes  int64
+}
+
+// NewPDFWriter creates a PDF writer with the given layout that saves to path when closed.
+func NewPDFWriter(path string, opts PDFOptions) *PDFWriter {
+	pdf := gofpdf.New("P", "mm", opts.PageSize, "")
+	pdf.SetMargins(opts.Margin, opts.Margin, opts.Margin)
+	pdf.SetAutoPageBreak(true, opts.Margin)
 
 	// Add a page and handle potential errors
 	pdf.AddPage()
 
-	// Set font
-	pdf.SetFont("Arial", "", 12)
+	// Set font, embedding the TrueType font when one is given so any script can be printed
+	if opts.FontFile != "" {
+		pdf.SetFontLocation(filepath.Dir(opts.FontFile)) // gofpdf resolves font files against its font directory
+		pdf.AddUTF8Font("content", "", filepath.Base(opts.FontFile))
+		pdf.SetFont("content", "", opts.FontSize)
+	} else {
+		pdf.SetFont("Arial", "", opts.FontSize)
+	}
 
-	// Sanitize content by removing unsupported characters
-	content = sanitizeText(content)
+	// Fit content between the margins, keeping the line spacing proportional to the font
+	pageWidth, pageHeight := pdf.GetPageSize()
+	w := &PDFWriter{
+		pdf:        pdf,
+		filepath:   path,
+		width:      pageWidth - 2*opts.Margin,
+		lineHeight: opts.FontSize * 10 / 12,
+		utf8:       opts.FontFile != "",
+	}
+	if opts.IncludeImages {
+		w.images = make(map[string]string)
+		w.maxImageHeight = pageHeight - 2*opts.Margin
+		w.maxImageBytes = opts.MaxImageBytes
+	}
+	return w
+}
 
-	// Define PDF layout width for content fitting
-	width := 190.0 // Effective content width for A4 paper in mm
+// AddText appends content to the PDF, wrapping it to the page width.
+func (w *PDFWriter) AddText(content string) {
+	// Sanitize content by removing characters the core font can't print
+	if !w.utf8 {
+		content = sanitizeText(content)
+	}
+
+	if w.images != nil {
+		w.addTextWithImages(content)
+		return
+	}
 
 	// Split content into lines that fit within the width
-	lines := pdf.SplitText(content, width)
+	w.addLines(w.wrapText(content))
+}
 
-	// Add each line to the PDF
+// addLines adds each wrapped line to the PDF.
+func (w *PDFWriter) addLines(lines []string) {
 	for _, line := range lines {
-		pdf.Cell(0, 10, line)
-		pdf.Ln(-1) // Line break
+		w.pdf.Cell(0, w.lineHeight, line)
+		w.pdf.Ln(-1) // Line break
 	}
+}
 
+// Close writes the PDF to its file.
+func (w *PDFWriter) Close() error {
 	// Output the PDF to file and handle errors
-	if err := pdf.OutputFileAndClose(filepath); err != nil {
+	if err := w.pdf.OutputFileAndClose(w.filepath); err != nil {
 		return fmt.Errorf("error writing PDF file: %w", err)
 	}
 
@@ -0,0 +1,84 @@
+package writer
+
+import (
+	"os"
+	"path/filepath"
+	"sitemapExport/crawler"
+	"sitemapExport/formatter"
+	"strings"
+	"testing"
+)
+
+func TestPDFStream(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "out")
+	var stream PageStream = NewPDFStream(filename, DefaultPDFOptions, formatter.Options{})
+	for _, page := range []crawler.Page{
+		{URL: "https://example.com/a", Title: "First", Content: "First page."},
+		{URL: "https://example.com/b", Title: "Second", Content: "Second page."},
+	} {
+		if err := stream.Write(page); err != nil {
+			t.Fatalf("Write: %v", err)
+		}
+	}
+	if _, err := os.Stat(filename + ".pdf"); !os.IsNotExist(err) {
+		t.Errorf("PDF written before the stream was closed: %v", err)
+	}
+	if err := stream.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	if stream.Count() != 2 {
+		t.Errorf("Count = %d, want 2", stream.Count())
+	}
+	if info, err := os.Stat(filename + ".pdf"); err != nil || info.Size() == 0 {
+		t.Errorf("no PDF written: %v", err)
+	}
+}
+
+func TestWritePagesSplitKeepsManifest(t *testing.T) {
+	dir := t.TempDir()
+	pages := []crawler.Page{
+		{URL: "https://example.com/manifest", Title: "Manifest", Content: "A page about manifests."},
+		{URL: "https://example.com/other", Title: "Other", Content: "Another page."},
+	}
+	files, err := WritePagesSplit(dir, pages, "json", false, DefaultPDFOptions, formatter.Options{})
+	if err != nil {
+		t.Fatalf("WritePagesSplit: %v", err)
+	}
+	manifestPath := filepath.Join(dir, SplitManifestFile)
+	for _, file := range files {
+		if file.Path == manifestPath {
+			t.Fatalf("page %s was written to the manifest's path", file.URL)
+		}
+	}
+	if err := WriteManifest(manifestPath, files); err != nil {
+		t.Fatalf("WriteManifest: %v", err)
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 3 {
+		t.Errorf("output directory has %d files, want both pages and the manifest", len(entries))
+	}
+}
+
+func TestAppendToFileTSVKeepsOneHeader(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "out")
+	for _, page := range []crawler.Page{
+		{URL: "https://example.com/a", Title: "First", Content: "First page."},
+		{URL: "https://example.com/b", Title: "Second", Content: "Second page."},
+	} {
+		content, err := formatter.FormatPages([]crawler.Page{page}, "tsv", formatter.Options{})
+		if err != nil {
+			t.Fatalf("FormatPages: %v", err)
+		}
+		if err := AppendToFile(filename, content, "tsv"); err != nil {
+			t.Fatalf("AppendToFile: %v", err)
+		}
+	}
+
+	data, err := os.ReadFile(filename + ".tsv")
+	if err != nil {
+		t.Fatalf("reading output: %v", err)
+	}
+	lines := strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+	if len(lines) != 3 || !strings.HasPrefix(lines[0], "Title\t") || !strings.HasPrefix(lines[1], "First\t") || !strings.HasPrefix(lines[2], "Second\t") {
+		t.Errorf("appended tsv = %q, want one header row and both pages", lines)
+	}
+}